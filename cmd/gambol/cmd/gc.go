@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"log/slog"
+	"os"
+	"path"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	gambol "github.com/nuccitheboss/gambol/internal/common"
+)
+
+const gcShortHelp = "Clean up after crashed or failed runs"
+const gcLongHelp = `Description:
+  Clean up instances, caches, and logs left behind by gambol runs that are
+  no longer active, e.g. because gambol crashed or a playthrough failed.
+
+  Instances are identified by the user.gambol.* metadata gambol tags
+  them with. The provider is configured from the gambol configuration file.
+
+  Instances created by gambol on other machines sharing the provider are
+  only cleaned up with --older-than, once they are older than that.
+
+  The logs of finished runs are kept for --logs-older-than, even if the
+  runs passed, and deleted afterwards.
+`
+const gcExamples = `  gambol gc
+      Clean up after all runs that are no longer active
+
+  gambol gc --older-than 24h
+      Also clean up runs that started more than a day ago
+
+  gambol gc --logs-older-than 168h
+      Also delete the logs of finished runs older than a week
+
+  gambol gc --dry-run
+      Print what would be cleaned up without deleting anything
+`
+
+var gcOpts gambol.GCOptions
+var gcCmd = &cobra.Command{
+	Use:     "gc",
+	Short:   gcShortHelp,
+	Long:    gcLongHelp,
+	Example: gcExamples,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if gcOpts.LogsDir == "" {
+			gcOpts.LogsDir = path.Join(viper.GetString("storage"), "runs")
+		}
+		err := gambol.GC(gcOpts)
+		if err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	gcCmd.Flags().StringVar(&gcOpts.Provider, "provider", "lxd", "provider to find leftover instances with")
+	gcCmd.Flags().DurationVar(&gcOpts.OlderThan, "older-than", 0, "also clean up active runs and runs of other machines older than this")
+	gcCmd.Flags().StringVar(&gcOpts.LogsDir, "logs-dir", "", "directory the logs of runs were written within (default \"<storage>/runs\")")
+	gcCmd.Flags().DurationVar(&gcOpts.LogsOlderThan, "logs-older-than", 30*24*time.Hour, "also delete logs of finished runs older than this, or never if 0")
+	gcCmd.Flags().BoolVar(&gcOpts.DryRun, "dry-run", false, "only print what would be cleaned up")
+}
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	gambol "github.com/nuccitheboss/gambol/internal/common"
+	"github.com/nuccitheboss/gambol/internal/report"
+)
+
+const historyShortHelp = "List past runs of playthroughs"
+const historyLongHelp = `Description:
+  List the runs recorded in the history of runs, newest first.
+
+  Every finished run is recorded with the results of its Acts and Scenes,
+  the checksum of its playthrough file, the git commit of the repository
+  the playthrough file is in, and the host it was executed on.
+`
+const historyExamples = `  gambol history
+      Print a table of the most recent runs
+
+  gambol history spec.yaml
+      Print a table of the most recent runs of the playthrough in spec.yaml
+
+  gambol history --limit 0 --json
+      Print all recorded runs as JSON
+`
+
+const showShortHelp = "Show the results of a past run"
+const showLongHelp = `Description:
+  Show the results of a run recorded in the history of runs.
+
+  The run can be referred to by its id or a unique prefix of its id.
+  Only the output of failed Scenes is recorded in the history.
+
+  An HTML report of the run can be written from the recorded results,
+  e.g. to share a run after its instances and logs are gone.
+`
+const showExamples = `  gambol show 3f2a
+      Print the results of run 3f2a...
+
+  gambol show 3f2a --json
+      Print the recorded run as JSON
+
+  gambol show 3f2a --html report.html
+      Write an HTML report of the recorded run
+`
+
+var historyJSON bool
+var historyLimit int
+var historyCmd = &cobra.Command{
+	Use:     "history [playthrough]",
+	Short:   historyShortHelp,
+	Long:    historyLongHelp,
+	Example: historyExamples,
+	Args:    cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var playthrough string
+		if len(args) == 1 {
+			playthrough = args[0]
+		}
+		records, err := gambol.History(playthrough)
+		if err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+		if historyLimit > 0 && len(records) > historyLimit {
+			records = records[:historyLimit]
+		}
+
+		if historyJSON {
+			err = printJSON(records)
+		} else {
+			err = printHistoryTable(records)
+		}
+		if err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+var showJSON bool
+var showHTML string
+var showCmd = &cobra.Command{
+	Use:     "show <run>",
+	Short:   showShortHelp,
+	Long:    showLongHelp,
+	Example: showExamples,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		record, err := gambol.FindRecord(args[0])
+		if err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+
+		switch {
+		case showHTML != "":
+			err = report.WriteHTML(showHTML, &record.PlayResult)
+		case showJSON:
+			err = printJSON(record)
+		default:
+			err = printRecord(record)
+		}
+		if err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	historyCmd.Flags().BoolVar(&historyJSON, "json", false, "print history as JSON")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "number of runs to list, or 0 for all runs")
+	showCmd.Flags().BoolVar(&showJSON, "json", false, "print run as JSON")
+	showCmd.Flags().StringVar(&showHTML, "html", "", "write an HTML report of the run to this file instead")
+}
+
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func printHistoryTable(records []gambol.RunRecord) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RUN\tPLAYTHROUGH\tSTATUS\tPASSED\tFAILED\tSKIPPED\tSTARTED\tDURATION\tCOMMIT")
+	for _, record := range records {
+		counts := sceneCounts(&record.PlayResult)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%s\t%s\t%s\n",
+			record.Run, record.Name, record.Status,
+			counts[gambol.StatusPassed], counts[gambol.StatusFailed], counts[gambol.StatusSkipped],
+			record.Started.Format(time.DateTime), record.Duration.Round(time.Millisecond),
+			orDash(shortCommit(record.Commit)))
+	}
+	return w.Flush()
+}
+
+func printRecord(record gambol.RunRecord) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Run:\t%s\n", record.Run)
+	fmt.Fprintf(w, "Playthrough:\t%s\n", record.Name)
+	fmt.Fprintf(w, "File:\t%s\n", record.File)
+	fmt.Fprintf(w, "SHA-256:\t%s\n", record.Hash)
+	fmt.Fprintf(w, "Commit:\t%s\n", orDash(record.Commit))
+	fmt.Fprintf(w, "Provider:\t%s\n", record.Provider)
+	fmt.Fprintf(w, "Host:\t%s (%s, %s/%s)\n", record.Host.Hostname, record.Host.User, record.Host.OS, record.Host.Arch)
+	fmt.Fprintf(w, "Status:\t%s\n", record.Status)
+	fmt.Fprintf(w, "Started:\t%s\n", record.Started.Format(time.DateTime))
+	fmt.Fprintf(w, "Finished:\t%s\n", record.Finished.Format(time.DateTime))
+	fmt.Fprintf(w, "Duration:\t%s\n", record.Duration.Round(time.Millisecond))
+	counts := sceneCounts(&record.PlayResult)
+	fmt.Fprintf(w, "Scenes:\t%d passed, %d failed, %d skipped\n",
+		counts[gambol.StatusPassed], counts[gambol.StatusFailed], counts[gambol.StatusSkipped])
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Fprintln(w, "ACT\tSCENE\tSTATUS\tEXIT CODE\tDURATION")
+	for _, act := range record.Acts {
+		fmt.Fprintf(w, "%s\t\t%s\t\t%s\n", act.Id, act.Status, stepDuration(act.Status, act.Duration))
+		for _, scene := range act.Scenes {
+			exitCode := "-"
+			if scene.Status != gambol.StatusSkipped {
+				exitCode = fmt.Sprint(scene.ExitCode)
+			}
+			fmt.Fprintf(w, "\t%s\t%s\t%s\t%s\n", scene.Name, scene.Status, exitCode, stepDuration(scene.Status, scene.Duration))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if record.Error != "" {
+		fmt.Printf("\nError: %s\n", record.Error)
+	}
+	for _, act := range record.Acts {
+		for _, scene := range act.Scenes {
+			if scene.Status != gambol.StatusFailed {
+				continue
+			}
+			fmt.Printf("\nOutput of Scene %q of Act %q:\n", scene.Name, act.Id)
+			for _, line := range strings.Split(strings.TrimSuffix(scene.Stdout+scene.Stderr, "\n"), "\n") {
+				fmt.Printf("  %s\n", line)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Count the Scenes of a run by status.
+func sceneCounts(result *gambol.PlayResult) map[string]int {
+	counts := map[string]int{}
+	for _, act := range result.Acts {
+		for _, scene := range act.Scenes {
+			counts[scene.Status]++
+		}
+	}
+
+	return counts
+}
+
+func stepDuration(status string, d time.Duration) string {
+	if status == gambol.StatusSkipped {
+		return "-"
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -39,6 +39,13 @@ func init() {
 	cobra.OnInitialize(initConfig)
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "set log level to verbose")
 	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(gcCmd)
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(shellCmd)
+	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(showCmd)
+	rootCmd.AddCommand(statsCmd)
 }
 
 // Initialize gambol configuration.
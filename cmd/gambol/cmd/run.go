@@ -1,12 +1,19 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"os"
+	"path"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	gambol "github.com/nuccitheboss/gambol/internal/common"
+	"github.com/nuccitheboss/gambol/internal/report"
+	"github.com/nuccitheboss/gambol/internal/tracing"
 )
 
 const runShortHelp = "Run gambol playthroughs"
@@ -15,8 +22,41 @@ const runLongHelp = `Description:
 `
 const examples = `  gambol run spec.yaml
       Run gambol playthrough specificed in spec.yaml
+
+  gambol run --keep spec.yaml
+      Run playthrough and keep its instances around for inspection
+
+  gambol run --scene-output failed spec.yaml
+      Only show the output of Scenes that fail
+
+  gambol run --junit report.xml spec.yaml
+      Run playthrough and write a JUnit XML report for CI
+
+  gambol run --html report.html spec.yaml
+      Run playthrough and write an HTML report to share with others
+
+  gambol run --logs-dir logs spec.yaml
+      Write the logs of every Scene to logs/<run-id>/ for CI to upload
+
+  gambol run --output json spec.yaml
+      Print one JSON event per line instead of human-readable progress
+
+  gambol run --ci github spec.yaml
+      Group the output of Scenes and annotate failures in GitHub Actions
+
+  gambol run --trace-endpoint http://localhost:4318 spec.yaml
+      Export a trace of the run to an OpenTelemetry collector
 `
 
+// Time to wait for the remaining spans of a run to be exported.
+const traceShutdownTimeout = 5 * time.Second
+
+var runOpts gambol.RunOptions
+var junitReport string
+var htmlReport string
+var eventsFile string
+var logsDir string
+var traceConfig tracing.Config
 var runCmd = &cobra.Command{
 	Use:     "run",
 	Short:   runShortHelp,
@@ -31,10 +71,118 @@ var runCmd = &cobra.Command{
 			os.Exit(2)
 		}
 
-		err = gambol.Run(play)
+		switch runOpts.SceneOutput {
+		case gambol.SceneOutputLive, gambol.SceneOutputFailed:
+		default:
+			slog.Error("invalid --scene-output, expected 'live' or 'failed'", "scene-output", runOpts.SceneOutput)
+			os.Exit(2)
+		}
+		switch runOpts.Output {
+		case gambol.OutputText:
+		case gambol.OutputJSON:
+			runOpts.Events = append(runOpts.Events, report.JSONEvents(os.Stdout))
+		default:
+			slog.Error("invalid --output, expected 'text' or 'json'", "output", runOpts.Output)
+			os.Exit(2)
+		}
+		switch runOpts.CI {
+		case "auto":
+			runOpts.CI = gambol.DetectCI()
+		case gambol.CIGitHub, gambol.CIGitLab, gambol.CINone:
+		default:
+			slog.Error("invalid --ci, expected 'auto', 'github', 'gitlab', or 'none'", "ci", runOpts.CI)
+			os.Exit(2)
+		}
+		if logsDir == "" {
+			logsDir = path.Join(viper.GetString("storage"), "runs")
+		}
+		runOpts.Events = append(runOpts.Events, report.Logs(logsDir))
+		if eventsFile != "" {
+			events, err := os.Create(eventsFile)
+			if err != nil {
+				slog.Error(err.Error())
+				os.Exit(2)
+			}
+			defer events.Close()
+			runOpts.Events = append(runOpts.Events, report.JSONEvents(events))
+		}
+
+		config := tracing.Config{}
+		if err := viper.UnmarshalKey("tracing", &config); err != nil {
+			slog.Error(err.Error())
+			os.Exit(2)
+		}
+		if traceConfig.Endpoint != "" {
+			config.Endpoint = traceConfig.Endpoint
+		}
+		if traceConfig.File != "" {
+			config.File = traceConfig.File
+		}
+		shutdown, err := tracing.Setup(cmd.Context(), config, rootCmd.Version)
+		if err != nil {
+			slog.Error("failed to set up tracing", "error", err)
+			os.Exit(2)
+		}
+
+		result, err := gambol.Run(cmd.Context(), play, runOpts)
 		if err != nil {
 			slog.Error(err.Error())
+		}
+		// Don't hold up the exit for long if the trace cannot be exported.
+		ctx, cancel := context.WithTimeout(context.Background(), traceShutdownTimeout)
+		if err := shutdown(ctx); err != nil {
+			slog.Warn("dropped spans of run that could not be exported", "error", err)
+		}
+		cancel()
+		if result != nil {
+			if runOpts.Output == gambol.OutputText {
+				printSummary(result)
+				fmt.Printf("Logs of run written to %s\n", report.LogDir(logsDir, result.Run))
+			}
+			writeReports(result)
+		}
+		if err != nil || !result.Passed() {
 			os.Exit(1)
 		}
 	},
 }
+
+func init() {
+	runCmd.Flags().BoolVar(&runOpts.Keep, "keep", false, "keep instances after the playthrough completes")
+	runCmd.Flags().StringVarP(&runOpts.Output, "output", "o", gambol.OutputText, "format of progress output (text, json)")
+	runCmd.Flags().StringVar(&logsDir, "logs-dir", "", "write logs of the run to a directory named after the run within this directory (default \"<storage>/runs\")")
+	runCmd.Flags().StringVar(&eventsFile, "events", "", "write events of the run to this file as JSON lines")
+	runCmd.Flags().StringVar(&junitReport, "junit", "", "write a JUnit XML report of the playthrough to this file")
+	runCmd.Flags().StringVar(&htmlReport, "html", "", "write an HTML report of the playthrough to this file")
+	runCmd.Flags().StringVar(&runOpts.CI, "ci", "auto", "format output for a CI system, detected from the environment by default (auto, github, gitlab, none)")
+	runCmd.Flags().StringVar(&traceConfig.Endpoint, "trace-endpoint", "", "export a trace of the run to this OTLP/HTTP endpoint")
+	runCmd.Flags().StringVar(&traceConfig.File, "trace-file", "", "write the spans of the run to this file as JSON")
+	runCmd.Flags().StringVar(&runOpts.SceneOutput, "scene-output", gambol.SceneOutputLive, "show output of all Scenes live, or only of failed Scenes (live, failed)")
+}
+
+// Print the number of Scenes that passed, failed, and were skipped.
+func printSummary(result *gambol.PlayResult) {
+	counts := sceneCounts(result)
+	fmt.Printf("Playthrough %s: %d passed, %d failed, %d skipped Scenes in %s\n",
+		result.Status, counts[gambol.StatusPassed], counts[gambol.StatusFailed],
+		counts[gambol.StatusSkipped], result.Duration.Round(time.Millisecond))
+}
+
+// Write the reports of the playthrough requested on the command line.
+func writeReports(result *gambol.PlayResult) {
+	if junitReport != "" {
+		if err := report.WriteJUnit(junitReport, result); err != nil {
+			slog.Error("failed to write JUnit report", "error", err)
+		}
+	}
+	if htmlReport != "" {
+		if err := report.WriteHTML(htmlReport, result); err != nil {
+			slog.Error("failed to write HTML report", "error", err)
+		}
+	}
+	if summary := os.Getenv("GITHUB_STEP_SUMMARY"); runOpts.CI == gambol.CIGitHub && summary != "" {
+		if err := report.AppendMarkdown(summary, result); err != nil {
+			slog.Error("failed to write GitHub step summary", "error", err)
+		}
+	}
+}
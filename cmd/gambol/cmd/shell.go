@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	gambol "github.com/nuccitheboss/gambol/internal/common"
+)
+
+const shellShortHelp = "Start an interactive shell within an Act instance"
+const shellLongHelp = `Description:
+  Start an interactive shell within the instance of an Act of a run.
+
+  The run can be given by its id or a unique prefix of its id, and the
+  instance is resolved from the Act id through the cache of the run.
+  Use ` + "`gambol run --keep`" + ` or keep-alive Acts to keep instances around.
+`
+const shellExamples = `  gambol shell 3f2a controller
+      Start a shell within the instance of Act controller of run 3f2a...
+`
+
+var shellCmd = &cobra.Command{
+	Use:     "shell <run> <act>",
+	Short:   shellShortHelp,
+	Long:    shellLongHelp,
+	Example: shellExamples,
+	Args:    cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		code, err := gambol.Shell(cmd.Context(), args[0], args[1])
+		if err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(code)
+	},
+}
+
+const execShortHelp = "Run a command within an Act instance"
+const execLongHelp = `Description:
+  Run a one-off command within the instance of an Act of a run and
+  exit with the exit code of the command.
+
+  The run can be given by its id or a unique prefix of its id, and the
+  instance is resolved from the Act id through the cache of the run.
+`
+const execExamples = `  gambol exec 3f2a controller -- sinfo
+      Run sinfo within the instance of Act controller of run 3f2a...
+
+  gambol exec --tty 3f2a controller -- top
+      Run top within a pseudo-terminal
+`
+
+var execTTY bool
+var execCmd = &cobra.Command{
+	Use:     "exec <run> <act> -- <command>...",
+	Short:   execShortHelp,
+	Long:    execLongHelp,
+	Example: execExamples,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if cmd.ArgsLenAtDash() != 2 || len(args) < 3 {
+			return errors.New("expected <run> <act> -- <command>...")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		code, err := gambol.Exec(cmd.Context(), args[0], args[1], args[2:], execTTY)
+		if err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(code)
+	},
+}
+
+func init() {
+	execCmd.Flags().BoolVarP(&execTTY, "tty", "t", false, "run command within a pseudo-terminal")
+}
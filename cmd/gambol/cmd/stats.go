@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	gambol "github.com/nuccitheboss/gambol/internal/common"
+)
+
+const statsShortHelp = "Show pass rates and durations of Scenes over past runs"
+const statsLongHelp = `Description:
+  Show statistics of the Scenes of a playthrough over its most recent runs
+  in the history of runs: how often each Scene passed, how often it was
+  retried, and its median (p50) and 95th percentile (p95) duration.
+
+  Scenes that both passed and failed in runs with identical inputs, i.e.
+  the same playthrough file and the same files pushed from the host, are
+  flagged as flaky.
+`
+const statsExamples = `  gambol stats spec.yaml
+      Print statistics of the Scenes of spec.yaml over its last 20 runs
+
+  gambol stats --last 100 "my playthrough"
+      Print statistics over the last 100 runs of the playthrough by name
+`
+
+var statsJSON bool
+var statsLast int
+var statsCmd = &cobra.Command{
+	Use:     "stats <playthrough>",
+	Short:   statsShortHelp,
+	Long:    statsLongHelp,
+	Example: statsExamples,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		stats, err := gambol.Stats(args[0], statsLast)
+		if err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+
+		if statsJSON {
+			err = printJSON(stats)
+		} else {
+			err = printStatsTable(stats)
+		}
+		if err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "print statistics as JSON")
+	statsCmd.Flags().IntVarP(&statsLast, "last", "n", 20, "number of most recent runs to compute statistics over, or 0 for all runs")
+}
+
+func printStatsTable(stats gambol.PlayStats) error {
+	fmt.Printf("Scenes of playthrough %s over the last %d runs:\n\n", stats.Playthrough, stats.Runs)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACT\tSCENE\tPASSED\tFAILED\tSKIPPED\tPASS RATE\tRETRIES\tP50\tP95\tFLAKY")
+	flaky := 0
+	for _, scene := range stats.Scenes {
+		passRate, p50, p95 := "-", "-", "-"
+		if scene.Passed+scene.Failed > 0 {
+			passRate = fmt.Sprintf("%.0f%%", 100*scene.PassRate)
+			p50 = scene.P50.Round(time.Millisecond).String()
+			p95 = scene.P95.Round(time.Millisecond).String()
+		}
+		mark := ""
+		if scene.Flaky {
+			mark = "yes"
+			flaky++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\t%d\t%s\t%s\t%s\n",
+			scene.Act, scene.Scene, scene.Passed, scene.Failed, scene.Skipped,
+			passRate, scene.Retries, p50, p95, mark)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if flaky > 0 {
+		fmt.Printf("\n%d of %d Scenes are flaky: they passed and failed in runs with identical inputs\n", flaky, len(stats.Scenes))
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	gambol "github.com/nuccitheboss/gambol/internal/common"
+	"github.com/nuccitheboss/gambol/internal/storage"
+)
+
+const statusShortHelp = "Show the status of in-flight and kept runs"
+const statusLongHelp = `Description:
+  Show the status of gambol runs recorded in the storage directory, which
+  Act and Scene each run is executing, and the state of their instances.
+
+  Instance state is checked with the provider of each run. Providers that
+  only track instances within the gambol process executing the run report
+  their instances as unknown.
+`
+const statusExamples = `  gambol status
+      Print a table of all recorded runs and their instances
+
+  gambol ps --json
+      Print the status of all recorded runs as JSON
+`
+
+var statusJSON bool
+var statusCmd = &cobra.Command{
+	Use:     "status",
+	Aliases: []string{"ps"},
+	Short:   statusShortHelp,
+	Long:    statusLongHelp,
+	Example: statusExamples,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runs, err := gambol.Status(cmd.Context())
+		if err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+
+		if statusJSON {
+			err = printStatusJSON(runs)
+		} else {
+			err = printStatusTable(runs)
+		}
+		if err != nil {
+			slog.Error(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "print status as JSON")
+}
+
+func printStatusJSON(runs []gambol.RunStatus) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(runs)
+}
+
+func printStatusTable(runs []gambol.RunStatus) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RUN\tPLAYTHROUGH\tPROVIDER\tSTATUS\tACT\tSCENE\tSTARTED")
+	for _, run := range runs {
+		status := run.Status
+		if status == storage.RunRunning && !run.Active {
+			// The gambol process executing the run is gone.
+			status = "crashed"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			run.Id, run.Playthrough, run.Provider, status,
+			orDash(run.Act), orDash(run.Scene), run.Started.Format(time.DateTime))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Fprintln(w, "RUN\tACT\tINSTANCE\tSTATE")
+	for _, run := range runs {
+		for _, instance := range run.Instances {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", run.Id, instance.Act, instance.Name, instance.State)
+		}
+	}
+	return w.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,148 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CI systems to format the progress output of a playthrough run for.
+const (
+	CINone   = "none"
+	CIGitHub = "github"
+	CIGitLab = "gitlab"
+)
+
+// Number of lines of Scene output to include in error annotations.
+const annotationLines = 20
+
+// Detect the CI system gambol is running within, if any.
+func DetectCI() string {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return CIGitHub
+	case os.Getenv("GITLAB_CI") == "true":
+		return CIGitLab
+	default:
+		return CINone
+	}
+}
+
+// `ciFormatter` folds the progress output of Acts and Scenes into
+// collapsible groups of the CI job log, and points out failed
+// Scenes in the playthrough file.
+type ciFormatter struct {
+	// Either `CIGitHub` or `CIGitLab`.
+	ci string
+
+	// Path of the playthrough file relative to the CI workspace.
+	file string
+
+	// Names of the open GitLab sections, innermost last.
+	sections []string
+
+	// Number of GitLab sections opened so far.
+	count int
+
+	// Token to resume processing GitHub workflow commands with
+	// once the output of the Scene being executed has ended.
+	token string
+}
+
+func newCIFormatter(ci string, file string) *ciFormatter {
+	workspace := os.Getenv("GITHUB_WORKSPACE")
+	if ci == CIGitLab {
+		workspace = os.Getenv("CI_PROJECT_DIR")
+	}
+	if workspace == "" {
+		workspace, _ = os.Getwd()
+	}
+	if rel, err := filepath.Rel(workspace, file); err == nil && !strings.HasPrefix(rel, "..") {
+		file = rel
+	}
+
+	return &ciFormatter{ci: ci, file: file}
+}
+
+// Open a group of the job log. GitHub Actions cannot nest groups,
+// so only Scenes are grouped there while Acts are printed as is.
+// Workflow commands are not processed within the groups of Scenes,
+// so that output of Scenes cannot be mistaken for commands.
+func (f *ciFormatter) begin(title string, scene bool) {
+	switch f.ci {
+	case CIGitHub:
+		if scene {
+			f.token = uuid.NewString()
+			fmt.Printf("::group::%s\n", title)
+			fmt.Printf("::stop-commands::%s\n", f.token)
+		} else {
+			fmt.Println(title)
+		}
+	case CIGitLab:
+		f.count++
+		name := fmt.Sprintf("gambol_%d", f.count)
+		f.sections = append(f.sections, name)
+		fmt.Printf("\x1b[0Ksection_start:%d:%s\r\x1b[0K%s\n", time.Now().Unix(), name, title)
+	}
+}
+
+// Close the innermost open group of the job log.
+func (f *ciFormatter) end(scene bool) {
+	switch f.ci {
+	case CIGitHub:
+		if scene {
+			fmt.Printf("::%s::\n", f.token)
+			fmt.Println("::endgroup::")
+		}
+	case CIGitLab:
+		if len(f.sections) == 0 {
+			return
+		}
+		name := f.sections[len(f.sections)-1]
+		f.sections = f.sections[:len(f.sections)-1]
+		fmt.Printf("\x1b[0Ksection_end:%d:%s\r\x1b[0K\n", time.Now().Unix(), name)
+	}
+}
+
+// Report a failure at a line of the playthrough file. GitHub Actions
+// shows it as an annotation of the file, GitLab as a highlighted line.
+func (f *ciFormatter) fail(line int, title string, message string) {
+	switch f.ci {
+	case CIGitHub:
+		fmt.Printf("::error file=%s,line=%d,title=%s::%s\n",
+			escapeProperty(f.file), line, escapeProperty(title), escapeData(message))
+	case CIGitLab:
+		fmt.Printf("\x1b[31;1m%s:%d: %s\x1b[0m\n%s\n", f.file, line, title, strings.TrimSuffix(message, "\n"))
+	}
+}
+
+// Escape the message of a GitHub Actions workflow command.
+func escapeData(s string) string {
+	s = strings.TrimSuffix(s, "\n")
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// Escape a property value of a GitHub Actions workflow command.
+func escapeProperty(s string) string {
+	return strings.NewReplacer(":", "%3A", ",", "%2C").Replace(escapeData(s))
+}
+
+// Print the title of an Act or Scene, opening a group in CI.
+func (e *executor) begin(title string, scene bool) {
+	if e.ci == nil {
+		e.printf("%s\n", title)
+		return
+	}
+	e.ci.begin(title, scene)
+}
+
+// Close the group of an Act or Scene in CI.
+func (e *executor) end(scene bool) {
+	if e.ci != nil {
+		e.ci.end(scene)
+	}
+}
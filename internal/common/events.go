@@ -0,0 +1,111 @@
+package common
+
+import (
+	"fmt"
+	"time"
+)
+
+// Formats of the progress output of a playthrough run.
+const (
+	// Print human-readable progress.
+	OutputText = "text"
+
+	// Only emit events, e.g. as JSON to standard output.
+	OutputJSON = "json"
+)
+
+// Types of events emitted during a playthrough run.
+const (
+	EventRunStarted           = "run.started"
+	EventRunFinished          = "run.finished"
+	EventActStarted           = "act.started"
+	EventActFinished          = "act.finished"
+	EventInstanceProvisioning = "instance.provisioning"
+	EventInstanceProvisioned  = "instance.provisioned"
+	EventArtifactPushed       = "artifact.pushed"
+	EventArtifactPulled       = "artifact.pulled"
+	EventSceneStarted         = "scene.started"
+	EventSceneOutput          = "scene.output"
+	EventSceneFinished        = "scene.finished"
+	EventCleanupStarted       = "cleanup.started"
+	EventCleanupFinished      = "cleanup.finished"
+)
+
+// `Event` is a step in the lifecycle of a playthrough run.
+// Fields that do not apply to the type of event are left empty.
+type Event struct {
+	// Type of the event.
+	Type string `json:"type"`
+
+	// Time the event occurred.
+	Time time.Time `json:"time"`
+
+	// Unique id of the run.
+	Run string `json:"run"`
+
+	// Name of the playthrough.
+	Playthrough string `json:"playthrough,omitempty"`
+
+	// Id of the Act the event belongs to.
+	Act string `json:"act,omitempty"`
+
+	// Name of the Scene the event belongs to.
+	Scene string `json:"scene,omitempty"`
+
+	// Name of the instance the event belongs to.
+	Instance string `json:"instance,omitempty"`
+
+	// Cache key or host path of a pushed or pulled artifact.
+	Artifact string `json:"artifact,omitempty"`
+
+	// Size of a pushed or pulled artifact tarball in bytes.
+	Size int `json:"size,omitempty"`
+
+	// Stream a line of Scene output was written to, `stdout` or `stderr`.
+	Stream string `json:"stream,omitempty"`
+
+	// Line of Scene output.
+	Line string `json:"line,omitempty"`
+
+	// Status of a finished run, Act, or Scene.
+	Status string `json:"status,omitempty"`
+
+	// Exit code of a finished Scene.
+	ExitCode *int `json:"exit_code,omitempty"`
+
+	// Duration of a finished step.
+	Duration time.Duration `json:"duration,omitempty"`
+
+	// Error that failed the step, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// `EventHandler` receives the events of a playthrough run.
+type EventHandler func(event Event)
+
+// Emit an event of the run to all event handlers. The current
+// Act and Scene of the run are filled in if not set.
+func (e *executor) emit(event Event) {
+	if len(e.opts.Events) == 0 {
+		return
+	}
+
+	event.Time = time.Now()
+	event.Run = e.state.Id
+	if event.Act == "" {
+		event.Act = e.state.Act
+	}
+	if event.Scene == "" {
+		event.Scene = e.state.Scene
+	}
+	for _, handler := range e.opts.Events {
+		handler(event)
+	}
+}
+
+// Print progress of the playthrough unless only emitting events.
+func (e *executor) printf(format string, a ...any) {
+	if e.opts.Output != OutputJSON {
+		fmt.Printf(format, a...)
+	}
+}
@@ -1,120 +1,475 @@
 package common
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
 
-	"github.com/nuccitheboss/gambol/internal/provider/lxd"
+	"github.com/nuccitheboss/gambol/internal/provider"
+	_ "github.com/nuccitheboss/gambol/internal/provider/fake"
+	_ "github.com/nuccitheboss/gambol/internal/provider/host"
+	_ "github.com/nuccitheboss/gambol/internal/provider/lxd"
+	_ "github.com/nuccitheboss/gambol/internal/provider/ssh"
 	"github.com/nuccitheboss/gambol/internal/storage"
+	"github.com/nuccitheboss/gambol/internal/tracing"
 )
 
-var (
-	provider lxd.Driver
-	cache    storage.Cache
-)
+// `RunOptions` control how a playthrough is run.
+type RunOptions struct {
+	// Keep Act instances and the playthrough cache around after the
+	// playthrough completes so that they can be inspected afterwards.
+	Keep bool
+
+	// How to show the output of Scenes, either `SceneOutputLive`
+	// or `SceneOutputFailed`. Defaults to `SceneOutputLive`.
+	SceneOutput string
+
+	// Format of the progress output, either `OutputText`
+	// or `OutputJSON`. Defaults to `OutputText`.
+	Output string
+
+	// Handlers to emit the events of the run to.
+	Events []EventHandler
+
+	// CI system to format the progress output for, e.g. `CIGitHub`.
+	// Defaults to `CINone`.
+	CI string
+}
+
+// `executor` runs the Acts of a playthrough using
+// instances requested from the configured provider.
+type executor struct {
+	// State of the playthrough run.
+	state storage.Run
+
+	// Provider of Act instances.
+	provider provider.Provider
+
+	// Playthrough cache for artifacts and instance ids.
+	cache storage.Cache
+
+	// Options of the playthrough run.
+	opts RunOptions
+
+	// Result of the playthrough run.
+	result *PlayResult
+
+	// Result of the Act being executed.
+	act *ActResult
+
+	// Formatter of the progress output in CI, if any.
+	ci *ciFormatter
+}
 
-// Run gambol playthrough.
-func Run(file string) error {
+// Run gambol playthrough. The result of the playthrough is
+// returned once it has started, even if the playthrough failed.
+// The run is traced as a single trace with the run as root span.
+func Run(ctx context.Context, file string, opts RunOptions) (*PlayResult, error) {
 	play, err := loadPlay(file)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	queue := assembleWorkQueue(play)
 
-	provider, err = lxd.New()
+	name, p, err := newProvider(play)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// Record the absolute path so the playthrough
+	// can be found again from other directories.
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := fileHash(path)
+	if err != nil {
+		return nil, err
+	}
+	inputs := inputsHash(hash, play)
+
+	owner, err := storage.Owner()
+	if err != nil {
+		return nil, err
+	}
+
+	state := storage.Run{
+		Id:          uuid.NewString(),
+		Playthrough: play.Name,
+		File:        path,
+		Provider:    name,
+		Owner:       owner,
+		Pid:         os.Getpid(),
+		Started:     time.Now(),
+		Status:      storage.RunRunning,
+	}
+	if err := storage.PutRun(state); err != nil {
+		return nil, err
+	}
+
+	cache, err := storage.NewCache(state.Id)
+	if err != nil {
+		return nil, err
 	}
 
-	cache, err = storage.NewCache(uuid.NewString())
+	e := newExecutor(state, p, cache, opts)
+	if resolved, err := yaml.Marshal(play); err == nil {
+		e.result.Playthrough = string(resolved)
+	}
+	ctx, span := tracing.Start(ctx, "gambol.run",
+		tracing.RunKey.String(state.Id),
+		tracing.PlaythroughKey.String(play.Name),
+	)
+	e.emit(Event{Type: EventRunStarted, Playthrough: play.Name})
+	err = e.run(ctx, queue)
+	tracing.End(span, err)
+	e.result.finish(err)
+	e.emit(Event{
+		Type:     EventRunFinished,
+		Status:   e.result.Status,
+		Duration: e.result.Duration,
+		Error:    e.result.Error,
+	})
+	if err := e.record(hash, inputs); err != nil {
+		slog.Warn("failed to record run in history", "error", err)
+	}
 	if err != nil {
+		// Keep the state of failed runs around so that
+		// `gambol gc` can clean up after them later.
+		if err := e.update(func(r *storage.Run) { r.Status = storage.RunFailed }); err != nil {
+			slog.Error(err.Error())
+		}
+		return e.result, err
+	}
+
+	if opts.Keep {
+		e.printf("Kept instances of run %s\n", state.Id)
+		return e.result, e.update(func(r *storage.Run) { r.Status = storage.RunKept })
+	}
+
+	return e.result, storage.DeleteRun(state.Id)
+}
+
+// Create the Act instance provider configured in the playthrough.
+func newProvider(play Play) (string, provider.Provider, error) {
+	if len(play.Provider) != 1 {
+		return "", nil, fmt.Errorf("playthrough must configure exactly one provider, expected one of: %v", provider.Names())
+	}
+
+	for name, node := range play.Provider {
+		p, err := provider.New(name, &node)
+		return name, p, err
+	}
+
+	return "", nil, nil
+}
+
+func newExecutor(state storage.Run, p provider.Provider, cache storage.Cache, opts RunOptions) *executor {
+	result := &PlayResult{
+		Run:     state.Id,
+		Name:    state.Playthrough,
+		File:    state.File,
+		Started: state.Started,
+		Acts:    []ActResult{},
+	}
+
+	e := &executor{state: state, provider: p, cache: cache, opts: opts, result: result}
+	if opts.CI != "" && opts.CI != CINone && opts.Output != OutputJSON {
+		e.ci = newCIFormatter(opts.CI, state.File)
+	}
+
+	return e
+}
+
+// Run the Acts in the work queue and tear down their instances afterwards.
+func (e *executor) run(ctx context.Context, queue WorkQueue) (err error) {
+	lifecycle, hasLifecycle := e.provider.(provider.Lifecycle)
+	if hasLifecycle {
+		if err := lifecycle.Setup(e.state.Id); err != nil {
+			return err
+		}
+		if !e.opts.Keep {
+			// Don't leak the resources of failed runs. Resources that
+			// are still used by instances kept for debugging cannot be
+			// torn down yet, and are left to `gambol gc`.
+			defer func() {
+				if err == nil {
+					return
+				}
+				if err := lifecycle.Teardown(); err != nil {
+					slog.Warn("failed to tear down resources of failed run", "error", err)
+				}
+			}()
+		}
+	}
+
+	if err := e.runActs(ctx, queue); err != nil {
 		return err
 	}
+	if err := e.update(func(r *storage.Run) { r.Act, r.Scene = "", "" }); err != nil {
+		return err
+	}
+	if e.opts.Keep {
+		return nil
+	}
 
-	if err := runActs(queue); err != nil {
+	e.emit(Event{Type: EventCleanupStarted})
+	if err := e.cleanup(ctx, lifecycle); err != nil {
 		return err
 	}
+	e.emit(Event{Type: EventCleanupFinished})
+
+	return nil
+}
+
+// Destroy the instances of the run and tear down its resources.
+func (e *executor) cleanup(ctx context.Context, lifecycle provider.Lifecycle) (err error) {
+	ctx, span := tracing.Start(ctx, "gambol.cleanup")
+	defer func() { tracing.End(span, err) }()
 
-	ids, err := cache.GetInstanceIds()
+	ids, err := e.cache.GetInstanceIds()
 	if err != nil {
 		return err
 	}
-	if err := provider.DestroyInstances(ids); err != nil {
+	if err := e.provider.DestroyInstances(ctx, ids); err != nil {
 		return err
 	}
-	cache.Flush()
+	if lifecycle != nil {
+		if err := lifecycle.Teardown(); err != nil {
+			return err
+		}
+	}
+	e.cache.Flush()
 
 	return nil
 }
 
-func runActs(queue WorkQueue) error {
+// Update and record the state of the playthrough run.
+func (e *executor) update(f func(r *storage.Run)) error {
+	f(&e.state)
+	return storage.PutRun(e.state)
+}
+
+// Run the Acts in the work queue in order. Once an
+// Act fails, the remaining Acts are skipped.
+func (e *executor) runActs(ctx context.Context, queue WorkQueue) error {
+	var failed error
 	for !queue.IsEmpty() {
 		act, err := queue.Pop()
 		if err != nil {
 			break
 		}
-		if err := runAct(act); err != nil {
-			return err
+		if failed != nil {
+			e.result.Acts = append(e.result.Acts, skippedAct(act, "a previous Act failed"))
+			continue
+		}
+
+		result, err := e.runAct(ctx, act)
+		e.result.Acts = append(e.result.Acts, result)
+		if err != nil {
+			failed = fmt.Errorf("act '%s' failed: %w", act.Id, err)
 		}
 	}
 
-	return nil
+	return failed
 }
 
-func runAct(act Act) error {
-	fmt.Printf("Executing Act: %s\n", act.Option.Name)
-	exists, err := provider.CheckIfInstanceExists(act.Option.RunOn)
+func (e *executor) runAct(ctx context.Context, act Act) (result ActResult, err error) {
+	ctx, span := tracing.Start(ctx, "gambol.act", tracing.ActKey.String(act.Id))
+	defer func() { tracing.End(span, err) }()
+
+	e.begin("Executing Act: "+act.Option.Name, false)
+	result = ActResult{
+		Id:        act.Id,
+		Name:      act.Option.Name,
+		RunOn:     act.Option.RunOn,
+		Started:   time.Now(),
+		Scenes:    []SceneResult{},
+		Artifacts: []ArtifactResult{},
+	}
+	e.act = &result
+	defer func() {
+		e.end(false)
+		// Failed Scenes are reported by themselves.
+		if err != nil && e.ci != nil && !slices.ContainsFunc(result.Scenes, failed) {
+			e.ci.fail(act.Line, fmt.Sprintf("Act '%s' failed", act.Id), err.Error())
+		}
+		// Scenes were never run if the Act failed before them.
+		if err != nil && len(result.Scenes) == 0 {
+			for _, scene := range act.Option.Scenes {
+				result.Scenes = append(result.Scenes, skippedScene(scene, err.Error()))
+			}
+		}
+		e.act = nil
+		result.finish(err)
+		e.state.Scene = ""
+		e.emit(Event{
+			Type:     EventActFinished,
+			Instance: result.Instance,
+			Status:   result.Status,
+			Duration: result.Duration,
+			Error:    result.Error,
+		})
+	}()
+	if err := e.update(func(r *storage.Run) { r.Act, r.Scene = act.Id, "" }); err != nil {
+		return result, err
+	}
+	e.emit(Event{Type: EventActStarted})
+
+	instanceId, err := e.cache.GetInstance(act.Option.RunOn)
 	if err != nil {
-		return err
+		return result, err
 	}
 
-	var instanceId string
-	if !exists {
-		if err := provider.CreateInstance(act.Id, act.Option.RunOn); err != nil {
-			return err
+	if instanceId == "" {
+		instanceId = provider.RunPrefix(e.state.Id) + "-" + act.Id
+		e.emit(Event{Type: EventInstanceProvisioning, Instance: instanceId})
+		metadata := provider.Metadata{
+			Run:         e.state.Id,
+			Playthrough: e.state.Playthrough,
+			Act:         act.Id,
+			Owner:       e.state.Owner,
+			Created:     time.Now(),
 		}
-		if err := cache.PutInstanceId(act.Id); err != nil {
-			return err
+		if err := e.provider.CreateInstance(ctx, instanceId, act.instanceOptions(metadata)); err != nil {
+			return result, err
 		}
-		instanceId = act.Id
-	} else {
-		instanceId = act.Option.RunOn
+	}
+	e.emit(Event{Type: EventInstanceProvisioned, Instance: instanceId})
+	span.SetAttributes(tracing.InstanceKey.String(instanceId))
+	result.Instance = instanceId
+	if err := e.cache.PutInstance(act.Id, instanceId); err != nil {
+		return result, err
 	}
 
 	if len(act.Option.Input) > 0 {
-		if err := push(instanceId, act.Option.Input); err != nil {
-			return err
+		if err := e.push(ctx, instanceId, act.Option.Input); err != nil {
+			return result, err
 		}
 	}
 
-	if err := runScenes(instanceId, act.Option.Scenes); err != nil {
-		return err
+	result.Scenes, err = e.runScenes(ctx, act, instanceId)
+	if err != nil {
+		return result, err
+	}
+	if err := e.update(func(r *storage.Run) { r.Scene = "" }); err != nil {
+		return result, err
 	}
 
 	if len(act.Option.Output) > 0 {
-		if err := pull(instanceId, act.Option.Output); err != nil {
-			return err
+		if err := e.pull(ctx, instanceId, act.Option.Output); err != nil {
+			return result, err
 		}
 	}
 
 	if !act.Option.KeepAlive {
-		if err := provider.StopInstance(instanceId); err != nil {
-			return err
+		if err := e.provider.StopInstance(ctx, instanceId); err != nil {
+			return result, err
 		}
 	}
 
-	return nil
+	return result, nil
 }
 
-func runScenes(id string, scenes []Scene) error {
-	for _, scene := range scenes {
-		fmt.Printf("Executing Scene: %s\n", scene.Name)
-		if err := provider.ExecInstance(id, scene.Run); err != nil {
-			return err
+// Run the Scenes of an Act in order. Once a Scene
+// fails, the remaining Scenes of the Act are skipped.
+func (e *executor) runScenes(ctx context.Context, act Act, id string) ([]SceneResult, error) {
+	results := []SceneResult{}
+	var failed error
+	for _, scene := range act.Option.Scenes {
+		if failed != nil {
+			results = append(results, skippedScene(scene, "a previous Scene failed"))
+			continue
+		}
+
+		result, err := e.runScene(ctx, act, id, scene)
+		results = append(results, result)
+		if err != nil {
+			failed = fmt.Errorf("scene '%s' failed: %w", scene.Name, err)
 		}
 	}
 
-	return nil
+	return results, failed
+}
+
+func (e *executor) runScene(ctx context.Context, act Act, id string, scene Scene) (result SceneResult, err error) {
+	ctx, span := tracing.Start(ctx, "gambol.scene",
+		tracing.ActKey.String(act.Id),
+		tracing.SceneKey.String(scene.Name),
+		tracing.InstanceKey.String(id),
+	)
+	text := e.opts.Output != OutputJSON
+	live := text && e.opts.SceneOutput != SceneOutputFailed
+	log := newSceneLog(act.Id, scene.Name, live)
+
+	e.begin("Executing Scene: "+scene.Name, true)
+	result = SceneResult{Name: scene.Name, Started: time.Now()}
+	defer func() {
+		e.end(true)
+		if err != nil && e.ci != nil {
+			title := fmt.Sprintf("Scene '%s' of Act '%s' failed", scene.Name, act.Id)
+			e.ci.fail(scene.Line, title, err.Error()+"\n"+log.tail(annotationLines))
+		}
+		span.SetAttributes(tracing.ExitCodeKey.Int(result.ExitCode))
+		tracing.End(span, err)
+		result.finish(err)
+		e.emit(Event{
+			Type:     EventSceneFinished,
+			Status:   result.Status,
+			ExitCode: &result.ExitCode,
+			Duration: result.Duration,
+			Error:    result.Error,
+		})
+	}()
+	if err := e.update(func(r *storage.Run) { r.Scene = scene.Name }); err != nil {
+		return result, err
+	}
+	e.emit(Event{Type: EventSceneStarted, Instance: id})
+
+	log.onLine = func(stderr bool, line string) {
+		stream := "stdout"
+		if stderr {
+			stream = "stderr"
+		}
+		e.emit(Event{Type: EventSceneOutput, Stream: stream, Line: line})
+	}
+	stdout, stderr := log.writers()
+
+	// Only failures of the script itself are retried. The output
+	// of every attempt is kept, and the last exit code is reported.
+	for {
+		result.Attempts++
+		err = e.provider.ExecInstance(ctx, id, scene.Run, stdout, stderr)
+		stdout.Flush()
+		stderr.Flush()
+
+		var exitErr *provider.ExitError
+		switch {
+		case err == nil:
+			result.ExitCode = 0
+		case errors.As(err, &exitErr):
+			result.ExitCode = exitErr.Code
+		default:
+			result.ExitCode = -1
+		}
+		if exitErr == nil || result.Attempts > scene.Retries || ctx.Err() != nil {
+			break
+		}
+		e.printf("Retrying Scene: %s (attempt %d of %d)\n", scene.Name, result.Attempts+1, scene.Retries+1)
+	}
+	result.Stdout, result.Stderr = log.output(false), log.output(true)
+	if err != nil && text && !live {
+		log.dump()
+	}
+
+	return result, err
 }
@@ -0,0 +1,371 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	"github.com/nuccitheboss/gambol/internal/provider"
+	"github.com/nuccitheboss/gambol/internal/provider/fake"
+)
+
+// `recordingProvider` wraps the fake provider to record the
+// instances the executor destroys.
+type recordingProvider struct {
+	provider.Provider
+
+	mu        sync.Mutex
+	destroyed []string
+}
+
+func (p *recordingProvider) DestroyInstances(ctx context.Context, ids []string) error {
+	p.mu.Lock()
+	p.destroyed = append(p.destroyed, ids...)
+	p.mu.Unlock()
+
+	return p.Provider.DestroyInstances(ctx, ids)
+}
+
+// Provider created by the last run of a playthrough using the `recording` provider.
+var recorder *recordingProvider
+
+func init() {
+	provider.Register("recording", func(node *yaml.Node) (provider.Provider, error) {
+		var config fake.Config
+		if node != nil {
+			if err := node.Decode(&config); err != nil {
+				return nil, err
+			}
+		}
+		p, err := fake.New(config)
+		if err != nil {
+			return nil, err
+		}
+		recorder = &recordingProvider{Provider: p}
+		return recorder, nil
+	})
+}
+
+// Run a playthrough with the fake provider, or the provider named by
+// driver, using a fresh storage directory. The events of the run are
+// returned along with its result.
+func runPlaythrough(t *testing.T, driver string, acts string, opts RunOptions) (*PlayResult, []Event, error) {
+	t.Helper()
+
+	dir := t.TempDir()
+	viper.Set("storage", filepath.Join(dir, "storage"))
+	t.Cleanup(func() { viper.Set("storage", "") })
+	if err := os.MkdirAll(filepath.Join(dir, "storage"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	playthrough := fmt.Sprintf("name: test\nprovider:\n  %s:\n    root: %s\nacts:\n%s",
+		driver, filepath.Join(dir, "instances"), acts)
+	file := filepath.Join(dir, "playthrough.yaml")
+	if err := os.WriteFile(file, []byte(playthrough), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var events []Event
+	opts.Output = OutputJSON
+	opts.Events = append(opts.Events, func(event Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	})
+
+	result, err := Run(context.Background(), file, opts)
+	return result, events, err
+}
+
+// Get the ids of the Acts in the order they were started.
+func startedActs(events []Event) []string {
+	var acts []string
+	for _, event := range events {
+		if event.Type == EventActStarted {
+			acts = append(acts, event.Act)
+		}
+	}
+
+	return acts
+}
+
+func TestRunActOrderAndRunOn(t *testing.T) {
+	result, events, err := runPlaythrough(t, "fake", `
+  first:
+    name: First
+    run-on: noble
+    keep-alive: true
+    scenes:
+      - name: Write state
+        run: echo first > state.txt
+  second:
+    name: Second
+    run-on: first
+    scenes:
+      - name: Read state
+        run: test "$(cat state.txt)" = first
+  third:
+    name: Third
+    run-on: noble
+    scenes:
+      - name: No state
+        run: test ! -e state.txt
+`, RunOptions{})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	if got, want := startedActs(events), []string{"first", "second", "third"}; !slices.Equal(got, want) {
+		t.Errorf("Acts started in order %v, want %v", got, want)
+	}
+	if len(result.Acts) != 3 {
+		t.Fatalf("got %d Act results, want 3", len(result.Acts))
+	}
+	first, second, third := result.Acts[0], result.Acts[1], result.Acts[2]
+	if second.Instance != first.Instance {
+		t.Errorf("Act 'second' ran on instance %s, want instance %s of Act 'first'", second.Instance, first.Instance)
+	}
+	if third.Instance == first.Instance {
+		t.Errorf("Act 'third' reused instance %s of Act 'first'", third.Instance)
+	}
+	for _, act := range result.Acts {
+		if act.Status != StatusPassed {
+			t.Errorf("Act '%s' %s: %s", act.Id, act.Status, act.Error)
+		}
+	}
+}
+
+func TestRunArtifacts(t *testing.T) {
+	host := t.TempDir()
+	input := filepath.Join(host, "input.txt")
+	if err := os.WriteFile(input, []byte("from host\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(host, "output")
+
+	result, _, err := runPlaythrough(t, "fake", fmt.Sprintf(`
+  producer:
+    name: Producer
+    run-on: noble
+    input:
+      - host-path: %s
+        path: input.txt
+    output:
+      - key: greeting
+        path: greeting.txt
+      - key: config
+        path: /etc/test
+    scenes:
+      - name: Produce
+        run: |
+          test "$(cat input.txt)" = "from host"
+          echo hello > greeting.txt
+          mkdir -p ${GAMBOL_INSTANCE_ROOT}/etc/test/sub
+          echo value > ${GAMBOL_INSTANCE_ROOT}/etc/test/sub/config
+  consumer:
+    name: Consumer
+    run-on: noble
+    input:
+      - key: greeting
+        path: received.txt
+      - key: config
+        path: /srv/config
+    output:
+      - host-path: %s
+        path: /srv/config
+    scenes:
+      - name: Consume
+        run: |
+          test "$(cat received.txt)" = hello
+          test "$(cat ${GAMBOL_INSTANCE_ROOT}/srv/config/sub/config)" = value
+`, input, output), RunOptions{})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(output, "sub", "config"))
+	if err != nil {
+		t.Fatalf("artifact was not pulled to the host: %v", err)
+	}
+	if string(data) != "value\n" {
+		t.Errorf("pulled artifact contains %q, want %q", data, "value\n")
+	}
+
+	var pushed, pulled int
+	for _, act := range result.Acts {
+		for _, artifact := range act.Artifacts {
+			if artifact.SHA256 == "" || artifact.Size == 0 {
+				t.Errorf("artifact %+v of Act '%s' has no checksum or size", artifact, act.Id)
+			}
+			switch artifact.Direction {
+			case ArtifactInput:
+				pushed++
+			case ArtifactOutput:
+				pulled++
+			}
+		}
+	}
+	if pushed != 3 || pulled != 3 {
+		t.Errorf("got %d pushed and %d pulled artifacts, want 3 and 3", pushed, pulled)
+	}
+}
+
+func TestRunSkipsAfterFailure(t *testing.T) {
+	result, _, err := runPlaythrough(t, "fake", `
+  failing:
+    name: Failing
+    run-on: noble
+    scenes:
+      - name: Pass
+        run: "true"
+      - name: Fail
+        run: exit 3
+      - name: Never
+        run: "true"
+  later:
+    name: Later
+    run-on: noble
+    scenes:
+      - name: Never either
+        run: "true"
+`, RunOptions{})
+	if err == nil {
+		t.Fatal("run passed, want it to fail")
+	}
+	if result.Status != StatusFailed {
+		t.Errorf("run %s, want %s", result.Status, StatusFailed)
+	}
+
+	failing := result.Acts[0]
+	if failing.Status != StatusFailed {
+		t.Errorf("Act 'failing' %s, want %s", failing.Status, StatusFailed)
+	}
+	statuses := []string{}
+	for _, scene := range failing.Scenes {
+		statuses = append(statuses, scene.Status)
+	}
+	if want := []string{StatusPassed, StatusFailed, StatusSkipped}; !slices.Equal(statuses, want) {
+		t.Errorf("Scenes of Act 'failing' %v, want %v", statuses, want)
+	}
+	if code := failing.Scenes[1].ExitCode; code != 3 {
+		t.Errorf("failed Scene exited with %d, want 3", code)
+	}
+
+	later := result.Acts[1]
+	if later.Status != StatusSkipped || later.SkippedReason == "" {
+		t.Errorf("Act 'later' %s with reason %q, want it skipped with a reason", later.Status, later.SkippedReason)
+	}
+	if len(later.Scenes) != 1 || later.Scenes[0].Status != StatusSkipped {
+		t.Errorf("Scenes of Act 'later' %+v, want one skipped Scene", later.Scenes)
+	}
+}
+
+func TestRunSkipsScenesOfActFailingBeforeThem(t *testing.T) {
+	result, _, err := runPlaythrough(t, "fake", fmt.Sprintf(`
+  missing:
+    name: Missing input
+    run-on: noble
+    input:
+      - host-path: %s
+        path: input.txt
+    scenes:
+      - name: Never
+        run: "true"
+`, filepath.Join(t.TempDir(), "missing")), RunOptions{})
+	if err == nil {
+		t.Fatal("run passed, want it to fail")
+	}
+
+	scenes := result.Acts[0].Scenes
+	if len(scenes) != 1 || scenes[0].Status != StatusSkipped || scenes[0].SkippedReason == "" {
+		t.Errorf("Scenes %+v, want one skipped Scene with a reason", scenes)
+	}
+}
+
+func TestRunDestroysInstances(t *testing.T) {
+	acts := `
+  first:
+    name: First
+    run-on: noble
+    scenes:
+      - name: Pass
+        run: "true"
+  second:
+    name: Second
+    run-on: noble
+    scenes:
+      - name: Pass
+        run: "true"
+`
+	result, events, err := runPlaythrough(t, "recording", acts, RunOptions{})
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	var created []string
+	for _, act := range result.Acts {
+		created = append(created, act.Instance)
+	}
+	destroyed := slices.Clone(recorder.destroyed)
+	slices.Sort(created)
+	slices.Sort(destroyed)
+	if !slices.Equal(destroyed, created) {
+		t.Errorf("destroyed instances %v, want %v", destroyed, created)
+	}
+	if !slices.ContainsFunc(events, func(e Event) bool { return e.Type == EventCleanupFinished }) {
+		t.Errorf("cleanup of the run did not finish")
+	}
+
+	if _, _, err := runPlaythrough(t, "recording", acts, RunOptions{Keep: true}); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if len(recorder.destroyed) != 0 {
+		t.Errorf("destroyed instances %v of kept run", recorder.destroyed)
+	}
+}
+
+func TestRunRetriesScenes(t *testing.T) {
+	result, _, err := runPlaythrough(t, "fake", `
+  flaky:
+    name: Flaky
+    run-on: noble
+    scenes:
+      - name: Pass on second attempt
+        retries: 2
+        run: |
+          echo attempt >> attempts.txt
+          test "$(wc -l < attempts.txt)" -eq 2
+      - name: Pass
+        run: "true"
+      - name: Always fail
+        retries: 1
+        run: exit 4
+`, RunOptions{})
+	if err == nil {
+		t.Fatal("run passed, want it to fail")
+	}
+
+	scenes := result.Acts[0].Scenes
+	want := []struct {
+		status   string
+		attempts int
+	}{{StatusPassed, 2}, {StatusPassed, 1}, {StatusFailed, 2}}
+	for i, scene := range scenes {
+		if scene.Status != want[i].status || scene.Attempts != want[i].attempts {
+			t.Errorf("Scene '%s' %s after %d attempts, want %s after %d",
+				scene.Name, scene.Status, scene.Attempts, want[i].status, want[i].attempts)
+		}
+	}
+	if code := scenes[2].ExitCode; code != 4 {
+		t.Errorf("failed Scene exited with %d, want 4", code)
+	}
+}
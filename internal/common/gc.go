@@ -0,0 +1,258 @@
+package common
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/nuccitheboss/gambol/internal/provider"
+	"github.com/nuccitheboss/gambol/internal/storage"
+)
+
+// `GCOptions` control what is cleaned up by `GC`.
+type GCOptions struct {
+	// Name of the provider to find leftover instances with.
+	Provider string
+
+	// Also clean up runs that are still active but started longer
+	// ago than this. Runs of other machines sharing the provider
+	// are only cleaned up if set. Disabled if zero.
+	OlderThan time.Duration
+
+	// Directory the log directories of runs are written within.
+	// Logs of collected runs are deleted too if set.
+	LogsDir string
+
+	// Also delete the logs of finished runs that were last written
+	// longer ago than this. Disabled if zero.
+	LogsOlderThan time.Duration
+
+	// Only print what would be cleaned up.
+	DryRun bool
+}
+
+// Clean up instances, caches, and run state left behind by runs
+// that are no longer active, e.g. because gambol crashed.
+func GC(opts GCOptions) error {
+	read := time.Now()
+	runs, err := storage.GetRuns()
+	if err != nil {
+		return err
+	}
+	known := map[string]storage.Run{}
+	for _, run := range runs {
+		known[run.Id] = run
+	}
+	old := func(created time.Time) bool {
+		return opts.OlderThan > 0 && time.Since(created) > opts.OlderThan
+	}
+	// Runs that started after the runs were read are missing from
+	// them, so look up unknown runs again before deeming them stale.
+	lookup := func(id string) (storage.Run, bool, error) {
+		if run, exists := known[id]; exists {
+			return run, true, nil
+		}
+		runs, err := storage.GetRuns()
+		if err != nil {
+			return storage.Run{}, false, err
+		}
+		i := slices.IndexFunc(runs, func(r storage.Run) bool { return r.Id == id })
+		if i < 0 {
+			return storage.Run{}, false, nil
+		}
+		known[id] = runs[i]
+		return runs[i], true, nil
+	}
+	stale := func(id string, created time.Time) bool {
+		if _, exists := known[id]; !exists && created.After(read) {
+			return false
+		}
+		run, exists, err := lookup(id)
+		if err != nil {
+			slog.Warn("failed to look up run, keeping it", "run", id, "error", err)
+			return false
+		}
+		if !exists || !run.IsActive() {
+			return true
+		}
+		if !run.Started.IsZero() {
+			created = run.Started
+		}
+		return old(created)
+	}
+
+	// Only runs recorded in the storage directory can be checked for
+	// a live gambol process. Instances of other machines sharing the
+	// provider are collected once they are old enough.
+	owner, err := storage.Owner()
+	if err != nil {
+		return err
+	}
+	staleInstance := func(instance provider.Instance) bool {
+		if instance.Metadata.Owner != owner {
+			return old(instance.Metadata.Created)
+		}
+		return stale(instance.Metadata.Run, instance.Metadata.Created)
+	}
+
+	p, err := provider.New(opts.Provider, nil)
+	if err != nil {
+		return err
+	}
+	collected := map[string]bool{}
+	if collector, ok := p.(provider.Collector); ok {
+		ids, err := collectInstances(collector, staleInstance, opts.DryRun)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			collected[id] = true
+		}
+	} else {
+		slog.Warn("provider does not support finding leftover instances", "provider", opts.Provider)
+	}
+
+	caches, err := storage.GetCacheNames()
+	if err != nil {
+		return err
+	}
+	for _, name := range caches {
+		if !stale(name, time.Time{}) {
+			continue
+		}
+		fmt.Printf("Deleting cache %s\n", name)
+		collected[name] = true
+		if opts.DryRun {
+			continue
+		}
+		if err := storage.DeleteCache(name); err != nil {
+			return err
+		}
+	}
+
+	for _, run := range runs {
+		if !stale(run.Id, run.Started) {
+			continue
+		}
+		collected[run.Id] = true
+		if opts.DryRun {
+			continue
+		}
+		if err := storage.DeleteRun(run.Id); err != nil {
+			return err
+		}
+	}
+
+	if opts.LogsDir != "" && opts.LogsOlderThan > 0 {
+		ids, err := expiredLogs(opts.LogsDir, opts.LogsOlderThan)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			run, exists, err := lookup(id)
+			if err != nil {
+				slog.Warn("failed to look up run, keeping its logs", "run", id, "error", err)
+				continue
+			}
+			if !exists || !run.IsActive() {
+				collected[id] = true
+			}
+		}
+	}
+	if opts.LogsDir != "" {
+		for _, id := range sortedKeys(collected) {
+			if err := deleteLogs(filepath.Join(opts.LogsDir, id), opts.DryRun); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Delete the log directory of a collected run, if it has one.
+func deleteLogs(dir string, dryRun bool) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	fmt.Printf("Deleting logs %s\n", dir)
+	if dryRun {
+		return nil
+	}
+
+	return os.RemoveAll(dir)
+}
+
+// Name of the log file every log directory of a run starts with.
+const runLog = "run.log"
+
+// Get the ids of the runs whose logs in dir were last written longer
+// ago than age. Directories without a run log are not logs of runs,
+// and are left alone.
+func expiredLogs(dir string, age time.Duration) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(dir, entry.Name(), runLog))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if time.Since(info.ModTime()) > age {
+			ids = append(ids, entry.Name())
+		}
+	}
+
+	return ids, nil
+}
+
+// Delete the instances of stale runs, returning the ids of the runs.
+func collectInstances(collector provider.Collector, stale func(provider.Instance) bool, dryRun bool) ([]string, error) {
+	instances, err := collector.ListInstances()
+	if err != nil {
+		return nil, err
+	}
+
+	staleRuns := map[string][]string{}
+	for _, instance := range instances {
+		if stale(instance) {
+			staleRuns[instance.Metadata.Run] = append(staleRuns[instance.Metadata.Run], instance.Name)
+		}
+	}
+	for _, id := range sortedKeys(staleRuns) {
+		fmt.Printf("Collecting run %s: %v\n", id, staleRuns[id])
+		if dryRun {
+			continue
+		}
+		if err := collector.CollectRun(id); err != nil {
+			return nil, err
+		}
+	}
+
+	return sortedKeys(staleRuns), nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
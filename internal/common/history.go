@@ -0,0 +1,199 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/nuccitheboss/gambol/internal/storage"
+)
+
+// `RunRecord` is a finished run in the history of runs.
+type RunRecord struct {
+	PlayResult
+
+	// Name of the provider the run requested instances from.
+	Provider string `json:"provider"`
+
+	// SHA-256 checksum of the playthrough file.
+	Hash string `json:"hash"`
+
+	// SHA-256 checksum of the playthrough file and of the files
+	// of every `host-path` input, taken when the run started.
+	Inputs string `json:"inputs,omitempty"`
+
+	// Commit of the git repository of the playthrough file, if any.
+	Commit string `json:"commit,omitempty"`
+
+	// Host the run was executed on.
+	Host HostInfo `json:"host"`
+}
+
+// `HostInfo` describes the host a run was executed on.
+type HostInfo struct {
+	Hostname string `json:"hostname"`
+	User     string `json:"user"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+}
+
+// Record the finished run in the history of runs. Only the output
+// of failed Scenes is kept to keep the history database small.
+func (e *executor) record(hash string, inputs string) error {
+	record := RunRecord{
+		PlayResult: *e.result,
+		Provider:   e.state.Provider,
+		Hash:       hash,
+		Inputs:     inputs,
+		Commit:     gitCommit(e.state.File),
+		Host:       hostInfo(),
+	}
+	record.Acts = make([]ActResult, len(e.result.Acts))
+	for i, act := range e.result.Acts {
+		act.Scenes = slices.Clone(act.Scenes)
+		for j := range act.Scenes {
+			if act.Scenes[j].Status != StatusFailed {
+				act.Scenes[j].Stdout, act.Scenes[j].Stderr = "", ""
+			}
+		}
+		record.Acts[i] = act
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return storage.PutHistory(record.Run, data)
+}
+
+// Get the history of runs, newest first. If playthrough is set, only
+// runs of playthroughs with that name or file path are returned.
+func History(playthrough string) ([]RunRecord, error) {
+	data, err := storage.GetHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	file, _ := filepath.Abs(playthrough)
+	records := []RunRecord{}
+	for _, d := range data {
+		var record RunRecord
+		if err := json.Unmarshal(d, &record); err != nil {
+			return nil, err
+		}
+		if playthrough != "" && record.Name != playthrough && record.File != file {
+			continue
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Started.After(records[j].Started) })
+
+	return records, nil
+}
+
+// Find a run in the history of runs by its id or a unique prefix of its id.
+func FindRecord(id string) (RunRecord, error) {
+	records, err := History("")
+	if err != nil {
+		return RunRecord{}, err
+	}
+
+	var matches []RunRecord
+	for _, record := range records {
+		if record.Run == id {
+			return record, nil
+		}
+		if strings.HasPrefix(record.Run, id) {
+			matches = append(matches, record)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return RunRecord{}, fmt.Errorf("run '%s' not found in history", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return RunRecord{}, fmt.Errorf("run id '%s' is ambiguous", id)
+	}
+}
+
+// Get the SHA-256 checksum of a file.
+func fileHash(file string) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Get the SHA-256 checksum of the inputs of a playthrough: its file,
+// given by hash, and the files of every `host-path` input declared by
+// its Acts. Files that cannot be read are left out, as pushing them
+// fails the run anyway.
+func inputsHash(hash string, play Play) string {
+	var paths []string
+	for _, act := range play.Acts {
+		for _, artifact := range act.Option.Input {
+			if artifact.HostPath != "" {
+				paths = append(paths, artifact.HostPath)
+			}
+		}
+	}
+	slices.Sort(paths)
+
+	h := sha256.New()
+	io.WriteString(h, hash)
+	for _, root := range slices.Compact(paths) {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return nil
+			}
+			defer f.Close()
+			fmt.Fprintf(h, "\x00%s\x00", path)
+			io.Copy(h, f)
+			return nil
+		})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get the commit checked out in the git repository
+// of a file, or nothing if it is not within one.
+func gitCommit(file string) string {
+	out, err := exec.Command("git", "-C", filepath.Dir(file), "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+func hostInfo() HostInfo {
+	info := HostInfo{OS: runtime.GOOS, Arch: runtime.GOARCH}
+	info.Hostname, _ = os.Hostname()
+	if u, err := user.Current(); err == nil {
+		info.User = u.Username
+	}
+
+	return info
+}
@@ -1,18 +1,21 @@
 package common
 
 import (
+	"context"
+
 	"github.com/nuccitheboss/gambol/internal/storage"
+	"github.com/nuccitheboss/gambol/internal/tracing"
 )
 
 // Push artifacts into an Act instance.
-func push(id string, artifacts []storage.Artifact) error {
+func (e *executor) push(ctx context.Context, id string, artifacts []storage.Artifact) error {
 	for _, artifact := range artifacts {
 		if artifact.HostPath != "" {
-			if err := pushHostPath(id, artifact); err != nil {
+			if err := e.pushHostPath(ctx, id, artifact); err != nil {
 				return err
 			}
 		} else {
-			if err := pushCache(id, artifact); err != nil {
+			if err := e.pushCache(ctx, id, artifact); err != nil {
 				return err
 			}
 		}
@@ -22,27 +25,35 @@ func push(id string, artifacts []storage.Artifact) error {
 }
 
 // Push artifact located in the playthrough cache into Act instance.
-func pushCache(id string, artifact storage.Artifact) error {
-	input, err := cache.GetArtifact(artifact.Key)
+func (e *executor) pushCache(ctx context.Context, id string, artifact storage.Artifact) (err error) {
+	ctx, span := e.artifactSpan(ctx, "gambol.push", id, artifact)
+	defer func() { tracing.End(span, err) }()
+
+	input, err := e.cache.GetArtifact(artifact.Key)
 	if err != nil {
 		return err
 	}
-	if err := provider.PutArtifact(id, artifact, input); err != nil {
+	if err := e.provider.PutArtifact(ctx, id, artifact, input); err != nil {
 		return err
 	}
+	e.transferred(ctx, ArtifactInput, id, artifact, input)
 
 	return nil
 }
 
 // Push artifact located on host into Act instance.
-func pushHostPath(id string, artifact storage.Artifact) error {
+func (e *executor) pushHostPath(ctx context.Context, id string, artifact storage.Artifact) (err error) {
+	ctx, span := e.artifactSpan(ctx, "gambol.push", id, artifact)
+	defer func() { tracing.End(span, err) }()
+
 	input, err := artifact.Wrap()
 	if err != nil {
 		return err
 	}
-	if err := provider.PutArtifact(id, artifact, input); err != nil {
+	if err := e.provider.PutArtifact(ctx, id, artifact, input); err != nil {
 		return err
 	}
+	e.transferred(ctx, ArtifactInput, id, artifact, input)
 
 	return nil
 }
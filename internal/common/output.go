@@ -1,18 +1,21 @@
 package common
 
 import (
+	"context"
+
 	"github.com/nuccitheboss/gambol/internal/storage"
+	"github.com/nuccitheboss/gambol/internal/tracing"
 )
 
 // Pull artifacts from an Act instance.
-func pull(id string, artifacts []storage.Artifact) error {
+func (e *executor) pull(ctx context.Context, id string, artifacts []storage.Artifact) error {
 	for _, artifact := range artifacts {
 		if artifact.HostPath != "" {
-			if err := pullHostPath(id, artifact); err != nil {
+			if err := e.pullHostPath(ctx, id, artifact); err != nil {
 				return err
 			}
 		} else {
-			if err := pullCache(id, artifact); err != nil {
+			if err := e.pullCache(ctx, id, artifact); err != nil {
 				return err
 			}
 		}
@@ -22,27 +25,35 @@ func pull(id string, artifacts []storage.Artifact) error {
 }
 
 // Pull artifact from an Act instance and store in playthrough cache.
-func pullCache(id string, artifact storage.Artifact) error {
-	output, err := provider.GetArtifact(id, artifact)
+func (e *executor) pullCache(ctx context.Context, id string, artifact storage.Artifact) (err error) {
+	ctx, span := e.artifactSpan(ctx, "gambol.pull", id, artifact)
+	defer func() { tracing.End(span, err) }()
+
+	output, err := e.provider.GetArtifact(ctx, id, artifact)
 	if err != nil {
 		return err
 	}
-	if err := cache.PutArtifact(artifact.Key, output); err != nil {
+	if err := e.cache.PutArtifact(artifact.Key, output); err != nil {
 		return err
 	}
+	e.transferred(ctx, ArtifactOutput, id, artifact, output)
 
 	return nil
 }
 
 // Pull artifact from an Act instance and unwrap on host.
-func pullHostPath(id string, artifact storage.Artifact) error {
-	output, err := provider.GetArtifact(id, artifact)
+func (e *executor) pullHostPath(ctx context.Context, id string, artifact storage.Artifact) (err error) {
+	ctx, span := e.artifactSpan(ctx, "gambol.pull", id, artifact)
+	defer func() { tracing.End(span, err) }()
+
+	output, err := e.provider.GetArtifact(ctx, id, artifact)
 	if err != nil {
 		return err
 	}
 	if err := artifact.Unwrap(output); err != nil {
 		return err
 	}
+	e.transferred(ctx, ArtifactOutput, id, artifact, output)
 
 	return nil
 }
@@ -1,9 +1,12 @@
 package common
 
 import (
+	"fmt"
 	"os"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/nuccitheboss/gambol/internal/provider"
 )
 
 // Load gambol playthrough file.
@@ -18,9 +21,36 @@ func loadPlay(file string) (play Play, err error) {
 		return play, err
 	}
 
+	err = validatePlay(play)
+	if err != nil {
+		return play, err
+	}
+
 	return play, nil
 }
 
+// Validate Act options that cannot be checked while unmarshalling.
+func validatePlay(play Play) error {
+	for _, act := range play.Acts {
+		switch act.Option.InstanceType {
+		case "", provider.InstanceTypeContainer, provider.InstanceTypeVirtualMachine:
+		default:
+			return fmt.Errorf(
+				"act '%s' has invalid instance-type '%s', expected '%s' or '%s'",
+				act.Id, act.Option.InstanceType,
+				provider.InstanceTypeContainer, provider.InstanceTypeVirtualMachine,
+			)
+		}
+		for _, scene := range act.Option.Scenes {
+			if scene.Retries < 0 {
+				return fmt.Errorf("scene '%s' of act '%s' has negative retries", scene.Name, act.Id)
+			}
+		}
+	}
+
+	return nil
+}
+
 // Assemble the work queue for the Act executor.
 //
 // FIXME: Work queue assembly is quite rudementary at the moment,
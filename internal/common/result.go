@@ -0,0 +1,240 @@
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/nuccitheboss/gambol/internal/storage"
+	"github.com/nuccitheboss/gambol/internal/tracing"
+)
+
+// Possible statuses of Scenes, Acts, and playthroughs.
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// `PlayResult` is the result of a playthrough run.
+type PlayResult struct {
+	// Unique id of the run.
+	Run string `json:"run"`
+
+	// Name of the playthrough.
+	Name string `json:"name"`
+
+	// Path to the playthrough file.
+	File string `json:"file"`
+
+	// Status of the playthrough.
+	Status string `json:"status"`
+
+	// Time the playthrough started and finished.
+	Started  time.Time     `json:"started"`
+	Finished time.Time     `json:"finished"`
+	Duration time.Duration `json:"duration"`
+
+	// Error that failed the playthrough, if any.
+	Error string `json:"error,omitempty"`
+
+	// Resolved playthrough as YAML.
+	Playthrough string `json:"playthrough"`
+
+	// Results of the Acts of the playthrough in order of execution.
+	Acts []ActResult `json:"acts"`
+}
+
+// `ActResult` is the result of an Act.
+type ActResult struct {
+	// Unique id of the Act.
+	Id string `json:"id"`
+
+	// Name of the Act.
+	Name string `json:"name"`
+
+	// Image or Act id the Act runs on.
+	RunOn string `json:"run_on"`
+
+	// Name of the instance the Act ran within.
+	Instance string `json:"instance,omitempty"`
+
+	// Status of the Act.
+	Status string `json:"status"`
+
+	// Time the Act started and finished.
+	Started  time.Time     `json:"started"`
+	Finished time.Time     `json:"finished"`
+	Duration time.Duration `json:"duration"`
+
+	// Error that failed the Act, if any.
+	Error string `json:"error,omitempty"`
+
+	// Reason the Act was skipped, if it was.
+	SkippedReason string `json:"skipped_reason,omitempty"`
+
+	// Results of the Scenes of the Act in order of execution.
+	Scenes []SceneResult `json:"scenes"`
+
+	// Artifacts pushed into and pulled from the Act instance.
+	Artifacts []ArtifactResult `json:"artifacts"`
+}
+
+// Directions of artifacts transferred to and from Act instances.
+const (
+	ArtifactInput  = "input"
+	ArtifactOutput = "output"
+)
+
+// `ArtifactResult` is an artifact pushed into or pulled from an Act instance.
+type ArtifactResult struct {
+	// Either `ArtifactInput` or `ArtifactOutput`.
+	Direction string `json:"direction"`
+
+	// Cache key of the artifact.
+	Key string `json:"key,omitempty"`
+
+	// Path of the artifact on the host.
+	HostPath string `json:"host_path,omitempty"`
+
+	// Path of the artifact within the Act instance.
+	Path string `json:"path"`
+
+	// Size of the artifact tarball in bytes.
+	Size int `json:"size"`
+
+	// SHA-256 checksum of the artifact tarball.
+	SHA256 string `json:"sha256"`
+}
+
+// `SceneResult` is the result of a Scene.
+type SceneResult struct {
+	// Name of the Scene.
+	Name string `json:"name"`
+
+	// Status of the Scene.
+	Status string `json:"status"`
+
+	// Exit code of the Scene script, or -1
+	// if the script could not be executed.
+	ExitCode int `json:"exit_code"`
+
+	// Time the Scene started and finished.
+	Started  time.Time     `json:"started"`
+	Finished time.Time     `json:"finished"`
+	Duration time.Duration `json:"duration"`
+
+	// Captured standard output and error of the Scene.
+	Stdout string `json:"stdout"`
+	Stderr string `json:"stderr"`
+
+	// Number of times the script of the Scene was run,
+	// which is more than one if it was retried.
+	Attempts int `json:"attempts"`
+
+	// Error that failed the Scene, if any.
+	Error string `json:"error,omitempty"`
+
+	// Reason the Scene was skipped, if it was.
+	SkippedReason string `json:"skipped_reason,omitempty"`
+}
+
+// Check if the playthrough passed.
+func (r *PlayResult) Passed() bool {
+	return r.Status == StatusPassed
+}
+
+// Record the end of the playthrough.
+func (r *PlayResult) finish(err error) {
+	r.Finished = time.Now()
+	r.Duration = r.Finished.Sub(r.Started)
+	r.Status, r.Error = status(err)
+}
+
+// Record the end of the Act.
+func (r *ActResult) finish(err error) {
+	r.Finished = time.Now()
+	r.Duration = r.Finished.Sub(r.Started)
+	r.Status, r.Error = status(err)
+}
+
+// Record the end of the Scene.
+func (r *SceneResult) finish(err error) {
+	r.Finished = time.Now()
+	r.Duration = r.Finished.Sub(r.Started)
+	r.Status, r.Error = status(err)
+}
+
+func status(err error) (string, string) {
+	if err != nil {
+		return StatusFailed, err.Error()
+	}
+
+	return StatusPassed, ""
+}
+
+// Start the span of an artifact pushed into or pulled from an Act instance.
+func (e *executor) artifactSpan(ctx context.Context, name string, id string, artifact storage.Artifact) (context.Context, trace.Span) {
+	key := artifact.Key
+	if artifact.HostPath != "" {
+		key = artifact.HostPath
+	}
+
+	return tracing.Start(ctx, name, tracing.InstanceKey.String(id), tracing.ArtifactKey.String(key))
+}
+
+// Record an artifact pushed into or pulled from an Act instance.
+func (e *executor) transferred(ctx context.Context, direction string, id string, artifact storage.Artifact, data []byte) {
+	trace.SpanFromContext(ctx).SetAttributes(tracing.SizeKey.Int(len(data)))
+	sum := sha256.Sum256(data)
+	if e.act != nil {
+		e.act.Artifacts = append(e.act.Artifacts, ArtifactResult{
+			Direction: direction,
+			Key:       artifact.Key,
+			HostPath:  artifact.HostPath,
+			Path:      artifact.Path,
+			Size:      len(data),
+			SHA256:    hex.EncodeToString(sum[:]),
+		})
+	}
+
+	event := Event{Type: EventArtifactPushed, Instance: id, Artifact: artifact.Key, Size: len(data)}
+	if direction == ArtifactOutput {
+		event.Type = EventArtifactPulled
+	}
+	if artifact.HostPath != "" {
+		event.Artifact = artifact.HostPath
+	}
+	e.emit(event)
+}
+
+// Check if a Scene failed.
+func failed(scene SceneResult) bool {
+	return scene.Status == StatusFailed
+}
+
+// Result of an Act that was skipped.
+func skippedAct(act Act, reason string) ActResult {
+	result := ActResult{
+		Id:            act.Id,
+		Name:          act.Option.Name,
+		RunOn:         act.Option.RunOn,
+		Status:        StatusSkipped,
+		SkippedReason: reason,
+		Scenes:        []SceneResult{},
+		Artifacts:     []ArtifactResult{},
+	}
+	for _, scene := range act.Option.Scenes {
+		result.Scenes = append(result.Scenes, skippedScene(scene, reason))
+	}
+
+	return result
+}
+
+// Result of a Scene that was skipped.
+func skippedScene(scene Scene, reason string) SceneResult {
+	return SceneResult{Name: scene.Name, Status: StatusSkipped, SkippedReason: reason}
+}
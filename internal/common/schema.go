@@ -6,6 +6,7 @@ import (
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/nuccitheboss/gambol/internal/provider"
 	"github.com/nuccitheboss/gambol/internal/storage"
 )
 
@@ -16,8 +17,9 @@ type Play struct {
 	Name string `yaml:"name"`
 
 	// Provider to use for providing the instances
-	// that act scenes will be run within.
-	Provider map[string]Provider `yaml:"provider"`
+	// that act scenes will be run within. The provider
+	// is keyed by name and mapped to its configuration.
+	Provider map[string]yaml.Node `yaml:"provider"`
 
 	// Acts of the playthrough. Each act is mapped
 	// to a single instance that scenes will be run within.
@@ -25,12 +27,6 @@ type Play struct {
 	// Acts map[string]Act `yaml:"acts"`
 }
 
-// `Provider` represents an Act instance provider.
-// Providers are used for providing the isolated instances
-// that Acts and their complementing scenes are executed within.
-type Provider struct {
-}
-
 type Acts []Act
 
 func (a *Acts) UnmarshalYAML(v *yaml.Node) error {
@@ -45,6 +41,7 @@ func (a *Acts) UnmarshalYAML(v *yaml.Node) error {
 		if err != nil {
 			return err
 		}
+		act.Line = v.Content[i].Line
 		err = v.Content[i+1].Decode(&act.Option)
 		if err != nil {
 			return err
@@ -54,6 +51,19 @@ func (a *Acts) UnmarshalYAML(v *yaml.Node) error {
 	return nil
 }
 
+func (a Acts) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, act := range a {
+		var option yaml.Node
+		if err := option.Encode(act.Option); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: act.Id}, &option)
+	}
+
+	return node, nil
+}
+
 // `Act` is a job to run within a single instance.
 // An instance will be provisioned by the provider for each Act.
 // Acts can also be executed within the same instance.
@@ -63,43 +73,101 @@ type Act struct {
 
 	// Options that will processed by executor.
 	Option ActOptions
+
+	// Line of the Act in the playthrough file.
+	Line int
+}
+
+// Options to create the Act instance with.
+func (a *Act) instanceOptions(metadata provider.Metadata) provider.InstanceOptions {
+	return provider.InstanceOptions{
+		Image:     a.Option.RunOn,
+		Type:      a.Option.InstanceType,
+		Resources: a.Option.Resources,
+		Config:    a.Option.Config,
+		Devices:   a.Option.Devices,
+		Profiles:  a.Option.Profiles,
+		Address:   a.Option.Address,
+		Act:       a.Id,
+		Metadata:  metadata,
+	}
 }
 
 type ActOptions struct {
 	// Name of Act.
-	Name string `yaml:"name"`
+	Name string `yaml:"name,omitempty"`
 
 	// Base image to run the act scenes within.
 	// The base image name must map to a valid
 	// image name within the instance instance provider.
 	// `On` can also be mapped to an already provisioned
 	// instance to execute act scenes within the same instance.
-	RunOn string `yaml:"run-on"`
+	RunOn string `yaml:"run-on,omitempty"`
+
+	// Type of instance to run the Act within.
+	// Either `container` (default) or `virtual-machine`.
+	InstanceType string `yaml:"instance-type,omitempty"`
+
+	// Resource limits of the Act instance.
+	Resources provider.Resources `yaml:"resources,omitempty"`
+
+	// Provider-specific configuration of the Act instance,
+	// e.g. `security.nesting` for LXD.
+	Config map[string]string `yaml:"config,omitempty"`
+
+	// Provider-specific devices to attach to the Act instance.
+	Devices map[string]map[string]string `yaml:"devices,omitempty"`
+
+	// Profiles to apply to the Act instance. Overrides
+	// the default profiles of the provider if set.
+	Profiles []string `yaml:"profiles,omitempty"`
+
+	// Static IPv4 address of the Act instance. Requires
+	// a provider with an isolated network configured.
+	Address string `yaml:"address,omitempty"`
 
 	// If true, keep instance running after Act execution has
 	// completed. Otherwise, shut down instance to free up resources
 	// for other acts. Useful for distributed systems testing.
-	KeepAlive bool `yaml:"keep-alive"`
+	KeepAlive bool `yaml:"keep-alive,omitempty"`
 
 	// Input data to push into act instance before executing scenes.
-	Input []storage.Artifact `yaml:"input"`
+	Input []storage.Artifact `yaml:"input,omitempty"`
 
 	// Output data to pull from act instance after scenes have been executed.
-	Output []storage.Artifact `yaml:"output"`
+	Output []storage.Artifact `yaml:"output,omitempty"`
 
 	// Act scenes. Each scene will be executed
 	// in sequential order within the act instance.
-	Scenes []Scene `yaml:"scenes"`
+	Scenes []Scene `yaml:"scenes,omitempty"`
 }
 
 // `Scene` is a step within an Act. Each Scene is
 // executed within the same Act instance in sequential order.
 type Scene struct {
 	// Name of the scene.
-	Name string `yaml:"name"`
+	Name string `yaml:"name,omitempty"`
 
 	// Run script to execute within the act instance.
-	Run string `yaml:"run"`
+	Run string `yaml:"run,omitempty"`
+
+	// Number of times to run the script again if it exits
+	// with a non-zero exit code, e.g. for flaky downloads.
+	Retries int `yaml:"retries,omitempty"`
+
+	// Line of the Scene in the playthrough file.
+	Line int `yaml:"-"`
+}
+
+func (s *Scene) UnmarshalYAML(v *yaml.Node) error {
+	// Decode into a type without this method to avoid recursing.
+	type scene Scene
+	if err := v.Decode((*scene)(s)); err != nil {
+		return err
+	}
+	s.Line = v.Line
+
+	return nil
 }
 
 // Queue of Acts to be handled by executor.
@@ -0,0 +1,85 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nuccitheboss/gambol/internal/provider"
+	"github.com/nuccitheboss/gambol/internal/storage"
+)
+
+// Default command of interactive sessions started with `Shell`.
+var shellCommand = []string{"bash", "-l"}
+
+// Start an interactive session within the instance of an Act of a run.
+func Shell(ctx context.Context, run string, act string) (int, error) {
+	return Exec(ctx, run, act, shellCommand, true)
+}
+
+// Run a command within the instance of an Act of a run with the terminal
+// of gambol attached to it, and return the exit code of the command.
+// The instance is started first if it was stopped once its Act completed.
+func Exec(ctx context.Context, run string, act string, command []string, tty bool) (int, error) {
+	state, err := findRun(run)
+	if err != nil {
+		return 0, err
+	}
+
+	cache := storage.OpenCache(state.Id)
+	instanceId, err := cache.GetInstance(act)
+	if err != nil {
+		return 0, err
+	}
+	if instanceId == "" {
+		return 0, fmt.Errorf("act '%s' has no instance in run %s", act, state.Id)
+	}
+
+	p, err := attachProvider(state)
+	if err != nil {
+		return 0, err
+	}
+	interactive, ok := p.(provider.Interactive)
+	if !ok {
+		return 0, fmt.Errorf("provider '%s' does not support interactive sessions", state.Provider)
+	}
+
+	active, err := p.CheckIfInstanceActive(ctx, instanceId)
+	if err != nil {
+		return 0, err
+	}
+	if !active {
+		if err := p.StartInstance(ctx, instanceId); err != nil {
+			return 0, err
+		}
+	}
+
+	return interactive.Interact(instanceId, command, tty)
+}
+
+// Find a recorded run by its id or a unique prefix of its id.
+func findRun(id string) (storage.Run, error) {
+	runs, err := storage.GetRuns()
+	if err != nil {
+		return storage.Run{}, err
+	}
+
+	var matches []storage.Run
+	for _, run := range runs {
+		if run.Id == id {
+			return run, nil
+		}
+		if strings.HasPrefix(run.Id, id) {
+			matches = append(matches, run)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return storage.Run{}, fmt.Errorf("run '%s' not found", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return storage.Run{}, fmt.Errorf("run id '%s' is ambiguous", id)
+	}
+}
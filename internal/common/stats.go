@@ -0,0 +1,143 @@
+package common
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// `PlayStats` are statistics of the Scenes of a playthrough
+// over its most recent runs in the history of runs.
+type PlayStats struct {
+	// Name of the playthrough.
+	Playthrough string `json:"playthrough"`
+
+	// Number of runs the statistics are computed over.
+	Runs int `json:"runs"`
+
+	// Statistics of each Scene in order of execution.
+	Scenes []SceneStats `json:"scenes"`
+}
+
+// `SceneStats` are statistics of a Scene over the runs of a playthrough.
+type SceneStats struct {
+	// Id of the Act the Scene belongs to.
+	Act string `json:"act"`
+
+	// Name of the Scene.
+	Scene string `json:"scene"`
+
+	// Number of runs the Scene passed, failed, and was skipped in.
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+
+	// Fraction of executed runs the Scene passed in.
+	PassRate float64 `json:"pass_rate"`
+
+	// Number of times the Scene was retried within runs.
+	Retries int `json:"retries"`
+
+	// Median and 95th percentile duration of the Scene.
+	P50 time.Duration `json:"p50"`
+	P95 time.Duration `json:"p95"`
+
+	// Whether the Scene both passed and failed in runs with identical
+	// inputs, i.e. the same playthrough file and host files.
+	Flaky bool `json:"flaky"`
+}
+
+// Compute statistics of the Scenes of a playthrough, by name or file
+// path, over its last runs in the history of runs. All recorded runs
+// are used if last is not positive.
+func Stats(playthrough string, last int) (PlayStats, error) {
+	records, err := History(playthrough)
+	if err != nil {
+		return PlayStats{}, err
+	}
+	if len(records) == 0 {
+		return PlayStats{}, fmt.Errorf("no runs of playthrough '%s' in history", playthrough)
+	}
+	if last > 0 && len(records) > last {
+		records = records[:last]
+	}
+
+	type sceneKey struct{ act, scene string }
+	var order []sceneKey
+	stats := map[sceneKey]*SceneStats{}
+	durations := map[sceneKey][]time.Duration{}
+	outcomes := map[sceneKey]map[string][]string{}
+
+	// Runs are newest first, so Scenes are ordered as in the latest
+	// run, followed by Scenes that have been removed since.
+	for _, record := range records {
+		inputs := runInputs(record)
+		for _, act := range record.Acts {
+			for _, scene := range act.Scenes {
+				key := sceneKey{act.Id, scene.Name}
+				s, ok := stats[key]
+				if !ok {
+					s = &SceneStats{Act: act.Id, Scene: scene.Name}
+					stats[key] = s
+					outcomes[key] = map[string][]string{}
+					order = append(order, key)
+				}
+
+				switch scene.Status {
+				case StatusPassed:
+					s.Passed++
+				case StatusFailed:
+					s.Failed++
+				default:
+					s.Skipped++
+					continue
+				}
+				s.Retries += max(scene.Attempts-1, 0)
+				durations[key] = append(durations[key], scene.Duration)
+				if !slices.Contains(outcomes[key][inputs], scene.Status) {
+					outcomes[key][inputs] = append(outcomes[key][inputs], scene.Status)
+				}
+			}
+		}
+	}
+
+	result := PlayStats{Playthrough: records[0].Name, Runs: len(records), Scenes: []SceneStats{}}
+	for _, key := range order {
+		s := stats[key]
+		if executed := s.Passed + s.Failed; executed > 0 {
+			s.PassRate = float64(s.Passed) / float64(executed)
+		}
+		s.P50 = percentile(durations[key], 50)
+		s.P95 = percentile(durations[key], 95)
+		for _, statuses := range outcomes[key] {
+			if len(statuses) > 1 {
+				s.Flaky = true
+			}
+		}
+		result.Scenes = append(result.Scenes, *s)
+	}
+
+	return result, nil
+}
+
+// Identify the inputs of a run. Runs recorded without a checksum
+// of their inputs are identified by their playthrough file only.
+func runInputs(record RunRecord) string {
+	if record.Inputs != "" {
+		return record.Inputs
+	}
+
+	return record.Hash
+}
+
+// Get the p-th percentile of durations using the nearest-rank method.
+func percentile(durations []time.Duration, p int) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	rank := (p*len(sorted) + 99) / 100
+
+	return sorted[max(rank-1, 0)]
+}
@@ -0,0 +1,127 @@
+package common
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"github.com/nuccitheboss/gambol/internal/provider"
+	"github.com/nuccitheboss/gambol/internal/storage"
+)
+
+// Possible states of an Act instance reported by `Status`.
+const (
+	InstanceRunning = "running"
+	InstanceStopped = "stopped"
+	InstanceUnknown = "unknown"
+)
+
+// `RunStatus` is the status of a recorded playthrough run.
+type RunStatus struct {
+	storage.Run
+
+	// Whether the run is still being executed by a gambol process.
+	Active bool `json:"active"`
+
+	// Instances of the run.
+	Instances []InstanceStatus `json:"instances"`
+}
+
+// `InstanceStatus` is the status of an Act instance of a run.
+type InstanceStatus struct {
+	// Id of the Act.
+	Act string `json:"act"`
+
+	// Name of the instance the Act runs within.
+	Name string `json:"name"`
+
+	// State of the instance as reported by the provider.
+	State string `json:"state"`
+}
+
+// Get the status of all recorded runs, oldest first, and cross-check
+// the state of their instances with the provider of each run.
+func Status(ctx context.Context) ([]RunStatus, error) {
+	runs, err := storage.GetRuns()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Started.Before(runs[j].Started) })
+
+	result := make([]RunStatus, 0, len(runs))
+	for _, run := range runs {
+		status := RunStatus{Run: run, Active: run.IsActive(), Instances: []InstanceStatus{}}
+
+		cache := storage.OpenCache(run.Id)
+		instances, err := cache.GetInstances()
+		if err != nil {
+			slog.Debug("no instances recorded for run", "run", run.Id, "error", err)
+		}
+
+		var p provider.Provider
+		if len(instances) > 0 {
+			p, err = attachProvider(run)
+			if err != nil {
+				slog.Warn("failed to check instance state", "run", run.Id, "error", err)
+			}
+		}
+		for _, act := range sortedKeys(instances) {
+			status.Instances = append(status.Instances, InstanceStatus{
+				Act:   act,
+				Name:  instances[act],
+				State: instanceState(ctx, p, instances[act]),
+			})
+		}
+
+		result = append(result, status)
+	}
+
+	return result, nil
+}
+
+// Create the provider of a run and attach it to the resources of the run.
+//
+// The provider is configured from the playthrough file of the run if it
+// can still be loaded, otherwise from the gambol configuration file.
+func attachProvider(run storage.Run) (provider.Provider, error) {
+	var p provider.Provider
+	play, err := loadPlay(run.File)
+	if node, ok := play.Provider[run.Provider]; err == nil && ok {
+		p, err = provider.New(run.Provider, &node)
+	} else {
+		p, err = provider.New(run.Provider, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if lifecycle, ok := p.(provider.Lifecycle); ok {
+		if err := lifecycle.Attach(run.Id); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Get the state of an instance. Providers that only track instances
+// within the gambol process that created them report them as unknown.
+func instanceState(ctx context.Context, p provider.Provider, id string) string {
+	if p == nil {
+		return InstanceUnknown
+	}
+
+	exists, err := p.CheckIfInstanceExists(ctx, id)
+	if err != nil || !exists {
+		return InstanceUnknown
+	}
+	active, err := p.CheckIfInstanceActive(ctx, id)
+	if err != nil {
+		return InstanceUnknown
+	}
+	if active {
+		return InstanceRunning
+	}
+
+	return InstanceStopped
+}
@@ -0,0 +1,150 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Modes of showing the output of Scenes.
+const (
+	// Stream the output of every Scene while it runs.
+	SceneOutputLive = "live"
+
+	// Only show the output of Scenes that fail, once they have failed.
+	SceneOutputFailed = "failed"
+)
+
+// `sceneLog` captures the output of a Scene, and streams
+// it line by line to the terminal if running live.
+type sceneLog struct {
+	mu sync.Mutex
+
+	// Prefix of every line written to the terminal.
+	prefix string
+
+	// Whether to stream lines to the terminal as they are written.
+	live bool
+
+	// Full captured output of the Scene.
+	lines []logLine
+
+	// Called with every line of output as it is captured.
+	onLine func(stderr bool, line string)
+}
+
+// `logLine` is a captured line of output of a Scene.
+type logLine struct {
+	// Whether the line was written to standard error.
+	stderr bool
+
+	text []byte
+}
+
+func newSceneLog(act string, scene string, live bool) *sceneLog {
+	return &sceneLog{prefix: fmt.Sprintf("[%s/%s] ", act, scene), live: live}
+}
+
+// Get writers for the standard output and error of the Scene.
+func (l *sceneLog) writers() (stdout *lineWriter, stderr *lineWriter) {
+	return &lineWriter{log: l}, &lineWriter{log: l, stderr: true}
+}
+
+// Get the captured standard output or error of the Scene.
+func (l *sceneLog) output(stderr bool) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var b strings.Builder
+	for _, line := range l.lines {
+		if line.stderr == stderr {
+			b.Write(line.text)
+			b.WriteByte('\n')
+		}
+	}
+
+	return b.String()
+}
+
+// Write all captured output of the Scene to the terminal.
+func (l *sceneLog) dump() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, line := range l.lines {
+		l.print(line)
+	}
+}
+
+// Get the last n lines of captured output of the Scene.
+func (l *sceneLog) tail(n int) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var b strings.Builder
+	for _, line := range l.lines[max(len(l.lines)-n, 0):] {
+		b.Write(line.text)
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+// Write a line to the terminal. Callers must hold the lock.
+func (l *sceneLog) print(line logLine) {
+	w := os.Stdout
+	if line.stderr {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, "%s%s\n", l.prefix, line.text)
+}
+
+// `lineWriter` splits the output written to it into lines, so that
+// lines of standard output and error are not interleaved midway.
+type lineWriter struct {
+	log *sceneLog
+
+	// Whether the writer captures standard error.
+	stderr bool
+
+	// Incomplete line written so far.
+	partial []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.line(w.partial[:i+1])
+		w.partial = w.partial[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Write out an incomplete last line once the Scene has finished.
+func (w *lineWriter) Flush() {
+	if len(w.partial) > 0 {
+		w.line(w.partial)
+		w.partial = nil
+	}
+}
+
+func (w *lineWriter) line(text []byte) {
+	w.log.mu.Lock()
+	defer w.log.mu.Unlock()
+
+	line := logLine{stderr: w.stderr, text: bytes.Clone(bytes.TrimSuffix(text, []byte("\n")))}
+	w.log.lines = append(w.log.lines, line)
+	if w.log.onLine != nil {
+		w.log.onLine(line.stderr, string(line.text))
+	}
+	if w.log.live {
+		w.log.print(line)
+	}
+}
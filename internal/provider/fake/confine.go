@@ -0,0 +1,142 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Top-level directories of the host that are mounted read-only into
+// instances, so that scripts can run the programs of the host.
+var systemDirs = []string{"usr", "bin", "sbin", "lib", "lib32", "lib64", "libx32"}
+
+// Device nodes of the host that are made available within instances.
+var devices = []string{"null", "zero", "full", "random", "urandom", "tty"}
+
+// Options of `unshare` to run a script in new user, mount, and pid
+// namespaces, with the user of gambol mapped to root.
+var unshareOptions = []string{"--user", "--map-root-user", "--mount", "--pid", "--fork", "--kill-child"}
+
+// Script that confines a Scene script to the root directory of an
+// instance. It mounts the system directories given as arguments and
+// a few device nodes of the host into the instance, mounts a fresh
+// `/proc`, and executes the Scene script chrooted to the instance.
+var confineScript = `
+set -e
+root=$1
+shift
+for dir in "$@"; do
+  mount --rbind "/${dir}" "${root}/${dir}"
+  mount -o remount,bind,ro "${root}/${dir}"
+done
+for dev in ` + strings.Join(devices, " ") + `; do
+  mount --bind "/dev/${dev}" "${root}/dev/${dev}"
+done
+mount -t proc proc "${root}/proc"
+exec chroot "${root}" /bin/sh -c 'cd /root && exec /bin/bash /root/.gambol/run'
+`
+
+// `layout` is how the system directories of the host are laid
+// out, e.g. `/bin` is a symbolic link to `usr/bin` on most hosts.
+type layout struct {
+	// System directories to mount into instances.
+	mounts []string
+
+	// Symbolic links to create in instances, keyed by name.
+	links map[string]string
+}
+
+// Check that scripts can be confined on this host, and
+// get the layout of the system directories of the host.
+func probe() (layout, error) {
+	l := layout{links: map[string]string{}}
+	for _, dir := range systemDirs {
+		info, err := os.Lstat("/" + dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return l, err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink("/" + dir)
+			if err != nil {
+				return l, err
+			}
+			l.links[dir] = target
+		} else if info.IsDir() {
+			l.mounts = append(l.mounts, dir)
+		}
+	}
+
+	args := append(append([]string{}, unshareOptions...), "true")
+	if out, err := exec.Command("unshare", args...).CombinedOutput(); err != nil {
+		return l, fmt.Errorf("cannot confine Scene scripts to instances, "+
+			"set `unconfined: true` to run them on the host instead: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return l, nil
+}
+
+// Lay out the root directory of an instance for scripts to be chrooted to.
+func (l layout) prepare(dir string) error {
+	for _, d := range append([]string{"etc", "dev", "proc"}, l.mounts...) {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			return err
+		}
+	}
+	for name, target := range l.links {
+		if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	for _, dev := range devices {
+		if err := os.WriteFile(filepath.Join(dir, "dev", dev), nil, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command to execute the Scene script of an instance confined to it.
+func (l layout) command(ctx context.Context, dir string) *exec.Cmd {
+	args := append(append([]string{}, unshareOptions...), "--", "sh", "-c", confineScript, "sh", dir)
+	cmd := exec.CommandContext(ctx, "unshare", append(args, l.mounts...)...)
+	cmd.Env = append(os.Environ(), "HOME=/root", "TMPDIR=/tmp", "GAMBOL_INSTANCE_ROOT=/")
+
+	return cmd
+}
+
+// Check that a path on the host is within the root directory of an
+// instance, also after following symbolic links. Scripts can create
+// links that point out of the instance, which must not be followed
+// when moving artifacts in or out of it.
+func within(root string, path string) error {
+	resolved := path
+	for {
+		r, err := filepath.EvalSymlinks(resolved)
+		if err == nil {
+			resolved = r
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		parent := filepath.Dir(resolved)
+		if parent == resolved {
+			break
+		}
+		resolved = parent
+	}
+
+	if rel, err := filepath.Rel(root, resolved); err != nil || !filepath.IsLocal(rel) {
+		return fmt.Errorf("path '%s' is outside of the instance", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,280 @@
+// Package fake implements an in-process Act instance provider.
+//
+// Instances are simulated as temporary directories on the host, and
+// Scene scripts are executed as local subprocesses confined to the
+// instance directory. The fake provider does not require LXD, so
+// it can be used to exercise the executor quickly and hermetically.
+//
+// Scripts are confined with user, mount, and pid namespaces: they are
+// chrooted to the instance directory, with the system directories of
+// the host such as `/usr` mounted read-only. Hosts without unprivileged
+// user namespaces can opt out of confinement with `unconfined: true`.
+package fake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nuccitheboss/gambol/internal/provider"
+	"github.com/nuccitheboss/gambol/internal/storage"
+)
+
+func init() {
+	provider.Register("fake", func(node *yaml.Node) (provider.Provider, error) {
+		var config Config
+		if node != nil {
+			if err := node.Decode(&config); err != nil {
+				return nil, err
+			}
+		}
+		return New(config)
+	})
+}
+
+// `Config` represents the configuration of the fake provider.
+type Config struct {
+	// Directory to create instance directories within.
+	// Defaults to the system temporary directory.
+	Root string `yaml:"root"`
+
+	// Run Scene scripts on the host without confining them to the
+	// instance directory. Only the working directory and `HOME` of
+	// scripts are set to the instance. Use with trusted playthroughs
+	// on hosts without unprivileged user namespaces only.
+	Unconfined bool `yaml:"unconfined"`
+}
+
+// `instance` is a simulated Act instance.
+type instance struct {
+	// Root directory of the instance.
+	dir string
+
+	// Whether the instance is running.
+	active bool
+}
+
+type Driver struct {
+	root string
+
+	// Layout of confined instances, unless scripts run unconfined.
+	layout     layout
+	unconfined bool
+
+	mu        sync.Mutex
+	instances map[string]*instance
+}
+
+func New(config Config) (*Driver, error) {
+	root := config.Root
+	if root == "" {
+		root = os.TempDir()
+	}
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, err
+	}
+	// Instance directories are compared with paths that have their
+	// symbolic links resolved, e.g. if the temporary directory is one.
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Driver{root: root, unconfined: config.Unconfined, instances: map[string]*instance{}}
+	if !p.unconfined {
+		if p.layout, err = probe(); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func (p *Driver) CheckIfInstanceExists(ctx context.Context, id string) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, exists := p.instances[id]
+	return exists, nil
+}
+
+func (p *Driver) CheckIfInstanceActive(ctx context.Context, id string) (bool, error) {
+	i, err := p.get(id)
+	if err != nil {
+		return false, err
+	}
+
+	return i.active, nil
+}
+
+func (p *Driver) CreateInstance(ctx context.Context, id string, options provider.InstanceOptions) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, exists := p.instances[id]; exists {
+		return fmt.Errorf("instance '%s' already exists", id)
+	}
+
+	dir, err := os.MkdirTemp(p.root, id+"-")
+	if err != nil {
+		return err
+	}
+	for _, d := range []string{"root/.gambol/input", "root/.gambol/output", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			return err
+		}
+	}
+	if !p.unconfined {
+		if err := p.layout.prepare(dir); err != nil {
+			return err
+		}
+	}
+
+	p.instances[id] = &instance{dir: dir, active: true}
+	return nil
+}
+
+func (p *Driver) StartInstance(ctx context.Context, id string) error {
+	i, err := p.get(id)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	i.active = true
+	return nil
+}
+
+func (p *Driver) StopInstance(ctx context.Context, id string) error {
+	i, err := p.get(id)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	i.active = false
+	return nil
+}
+
+func (p *Driver) DestroyInstances(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		i, err := p.get(id)
+		if err != nil {
+			return err
+		}
+		if err := os.RemoveAll(i.dir); err != nil {
+			return err
+		}
+
+		p.mu.Lock()
+		delete(p.instances, id)
+		p.mu.Unlock()
+	}
+
+	return nil
+}
+
+// Execute script as a local subprocess confined to the instance. The
+// working directory and `HOME` are set to the `/root` directory of the
+// instance, and `GAMBOL_INSTANCE_ROOT` is set to the root directory of
+// the instance, i.e. `/` unless scripts run unconfined.
+func (p *Driver) ExecInstance(ctx context.Context, id string, script string, stdout io.Writer, stderr io.Writer) error {
+	i, err := p.get(id)
+	if err != nil {
+		return err
+	}
+	if !i.active {
+		return fmt.Errorf("instance '%s' is not running", id)
+	}
+
+	home := filepath.Join(i.dir, "root")
+	run := filepath.Join(home, ".gambol", "run")
+	if err := os.WriteFile(run, []byte(script), 0644); err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	if p.unconfined {
+		cmd = exec.CommandContext(ctx, "bash", run)
+		cmd.Dir = home
+		cmd.Env = append(os.Environ(), "HOME="+home, "TMPDIR="+filepath.Join(i.dir, "tmp"), "GAMBOL_INSTANCE_ROOT="+i.dir)
+	} else {
+		cmd = p.layout.command(ctx, i.dir)
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return err
+		}
+		return &provider.ExitError{Code: exitErr.ExitCode()}
+	}
+
+	return nil
+}
+
+// Get Artifact from Act instance.
+func (p *Driver) GetArtifact(ctx context.Context, id string, artifact storage.Artifact) ([]byte, error) {
+	i, err := p.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	path := i.path(artifact.Path)
+	matches, err := filepath.Glob(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) > 0 {
+		path = matches[0]
+	}
+	if err := within(i.dir, path); err != nil {
+		return nil, err
+	}
+
+	return archive(path)
+}
+
+// Put (upload) Artifact into Act instance.
+func (p *Driver) PutArtifact(ctx context.Context, id string, artifact storage.Artifact, input []byte) error {
+	i, err := p.get(id)
+	if err != nil {
+		return err
+	}
+
+	return extract(input, i.dir, i.path(artifact.Path))
+}
+
+func (p *Driver) get(id string) (*instance, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i, exists := p.instances[id]
+	if !exists {
+		return nil, fmt.Errorf("instance '%s' not found", id)
+	}
+
+	return i, nil
+}
+
+// Map a path within the instance to a path on the host. Relative
+// paths are resolved against the `/root` directory of the instance.
+// Paths never leave the instance directory, as `..` cannot go above
+// the root directory of the instance.
+func (i *instance) path(path string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join("/root", path)
+	}
+
+	return filepath.Join(i.dir, filepath.Clean(path))
+}
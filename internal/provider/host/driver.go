@@ -0,0 +1,314 @@
+// Package host implements an Act instance provider that runs
+// Scenes directly on the local machine.
+//
+// Useful for quick iteration, and for CI runners that are themselves
+// disposable virtual machines. Instances are either no-ops, or a per-Act
+// scratch directory if `scratch-dir` is configured. Artifacts are copied
+// locally using the same tar format as the other providers.
+package host
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nuccitheboss/gambol/internal/provider"
+	"github.com/nuccitheboss/gambol/internal/storage"
+)
+
+func init() {
+	provider.Register("host", func(node *yaml.Node) (provider.Provider, error) {
+		var config Config
+		if node != nil {
+			if err := node.Decode(&config); err != nil {
+				return nil, err
+			}
+		}
+		return New(config)
+	})
+}
+
+// `Config` represents the configuration of the host provider.
+type Config struct {
+	// Directory to create per-Act scratch directories within. Scenes
+	// are executed within the scratch directory of their Act. If not
+	// set, Scenes are executed within the current working directory.
+	ScratchDir string `yaml:"scratch-dir"`
+
+	// User to execute Scenes as. Requires `sudo` if set. The scratch
+	// directories, and artifacts pushed into them, are owned by the
+	// user. Artifacts pushed elsewhere keep the owner of gambol.
+	User string `yaml:"user"`
+}
+
+// `instance` is an Act "instance" on the local machine.
+type instance struct {
+	// Working directory of the instance.
+	dir string
+
+	// True if `dir` is a scratch directory owned by the instance.
+	scratch bool
+
+	// Whether the instance is running.
+	active bool
+}
+
+type Driver struct {
+	config Config
+
+	mu        sync.Mutex
+	instances map[string]*instance
+}
+
+func New(config Config) (*Driver, error) {
+	if config.ScratchDir != "" {
+		dir, err := filepath.Abs(config.ScratchDir)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+		config.ScratchDir = dir
+	}
+	if config.User != "" {
+		if _, err := exec.LookPath("sudo"); err != nil {
+			return nil, fmt.Errorf("running scenes as user '%s' requires sudo: %w", config.User, err)
+		}
+	}
+
+	return &Driver{config: config, instances: map[string]*instance{}}, nil
+}
+
+func (p *Driver) CheckIfInstanceExists(ctx context.Context, id string) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, exists := p.instances[id]
+	return exists, nil
+}
+
+func (p *Driver) CheckIfInstanceActive(ctx context.Context, id string) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i, exists := p.instances[id]
+	if !exists {
+		return false, fmt.Errorf("instance '%s' not found", id)
+	}
+
+	return i.active, nil
+}
+
+// Create an Act "instance". The image and instance type are
+// ignored since Scenes are always executed on the local machine.
+func (p *Driver) CreateInstance(ctx context.Context, id string, options provider.InstanceOptions) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, exists := p.instances[id]; exists {
+		return fmt.Errorf("instance '%s' already exists", id)
+	}
+
+	i := &instance{active: true}
+	if p.config.ScratchDir != "" {
+		dir, err := os.MkdirTemp(p.config.ScratchDir, id+"-")
+		if err != nil {
+			return err
+		}
+		if err := p.chown(ctx, dir, dir); err != nil {
+			return err
+		}
+		i.dir = dir
+		i.scratch = true
+	} else {
+		dir, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		i.dir = dir
+	}
+
+	p.instances[id] = i
+	return nil
+}
+
+func (p *Driver) StartInstance(ctx context.Context, id string) error {
+	return p.setActive(id, true)
+}
+
+func (p *Driver) StopInstance(ctx context.Context, id string) error {
+	return p.setActive(id, false)
+}
+
+func (p *Driver) DestroyInstances(ctx context.Context, ids []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, id := range ids {
+		i, exists := p.instances[id]
+		if !exists {
+			continue
+		}
+		if i.scratch {
+			if err := os.RemoveAll(i.dir); err != nil {
+				return err
+			}
+		}
+		delete(p.instances, id)
+	}
+
+	return nil
+}
+
+func (p *Driver) ExecInstance(ctx context.Context, id string, script string, stdout io.Writer, stderr io.Writer) error {
+	i, err := p.get(id)
+	if err != nil {
+		return err
+	}
+
+	run, err := os.CreateTemp("", "gambol-run-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(run.Name())
+	if _, err := run.WriteString(script); err != nil {
+		run.Close()
+		return err
+	}
+	if err := run.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(run.Name(), 0644); err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	if p.config.User != "" {
+		cmd = exec.CommandContext(ctx, "sudo", "--non-interactive", "--set-home", "--user", p.config.User, "--", "bash", run.Name())
+	} else {
+		cmd = exec.CommandContext(ctx, "bash", run.Name())
+	}
+	cmd.Dir = i.dir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return err
+		}
+		return &provider.ExitError{Code: exitErr.ExitCode()}
+	}
+
+	return nil
+}
+
+// Get Artifact from Act instance.
+func (p *Driver) GetArtifact(ctx context.Context, id string, artifact storage.Artifact) ([]byte, error) {
+	i, err := p.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	path := i.path(artifact.Path)
+	matches, err := filepath.Glob(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) > 0 {
+		path = matches[0]
+	}
+
+	local := storage.Artifact{HostPath: path}
+	return local.Wrap()
+}
+
+// Put (upload) Artifact into Act instance.
+func (p *Driver) PutArtifact(ctx context.Context, id string, artifact storage.Artifact, input []byte) error {
+	i, err := p.get(id)
+	if err != nil {
+		return err
+	}
+
+	path := i.path(artifact.Path)
+	local := storage.Artifact{HostPath: path}
+	if err := local.Unwrap(input); err != nil {
+		return err
+	}
+
+	// Artifacts pushed outside of the scratch directory are left as is.
+	if rel, err := filepath.Rel(i.dir, path); !i.scratch || err != nil || !filepath.IsLocal(rel) {
+		return nil
+	}
+	return p.chown(ctx, i.dir, path)
+}
+
+func (p *Driver) get(id string) (*instance, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i, exists := p.instances[id]
+	if !exists {
+		return nil, fmt.Errorf("instance '%s' not found", id)
+	}
+	if !i.active {
+		return nil, fmt.Errorf("instance '%s' is not running", id)
+	}
+
+	return i, nil
+}
+
+func (p *Driver) setActive(id string, active bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i, exists := p.instances[id]
+	if !exists {
+		return fmt.Errorf("instance '%s' not found", id)
+	}
+
+	i.active = active
+	return nil
+}
+
+// Hand ownership of path over to the configured user so that Scenes
+// can modify scratch directories and pushed artifacts. Only paths within
+// the scratch directory are handed over, also after following symbolic
+// links, so that artifacts cannot be used to take over files of the host.
+func (p *Driver) chown(ctx context.Context, scratch string, path string) error {
+	if p.config.User == "" {
+		return nil
+	}
+
+	dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	if scratch, err = filepath.EvalSymlinks(scratch); err != nil {
+		return err
+	}
+	resolved := filepath.Join(dir, filepath.Base(path))
+	if rel, err := filepath.Rel(scratch, resolved); err != nil || !filepath.IsLocal(rel) {
+		return fmt.Errorf("refusing to hand '%s' outside of scratch directory '%s' over to user '%s'", path, scratch, p.config.User)
+	}
+
+	// Symbolic links are not followed when changing owners recursively.
+	return exec.CommandContext(ctx, "sudo", "--non-interactive", "chown", "-R", "-P", p.config.User, resolved).Run()
+}
+
+// Resolve a path within the instance. Relative paths are
+// resolved against the working directory of the instance.
+func (i *instance) path(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(i.dir, path)
+}
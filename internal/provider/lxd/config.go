@@ -0,0 +1,99 @@
+package lxd
+
+import (
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// Default path to the LXD Unix socket when LXD is installed as a snap.
+const defaultSocket = "/var/snap/lxd/common/lxd/unix.socket"
+
+// Default image server used to resolve `run-on` image aliases.
+const defaultImageServer = "https://cloud-images.ubuntu.com/releases"
+
+// `Config` represents the configuration of the LXD provider.
+// Configuration is first read from the `provider.lxd` section of
+// the gambol configuration file, and then overridden by the
+// `provider.lxd` mapping in the playthrough file.
+type Config struct {
+	// Path to the Unix socket of the local LXD server.
+	// Ignored if `remote` is set.
+	Socket string `yaml:"socket" mapstructure:"socket"`
+
+	// URL of a remote LXD server, e.g. `https://lxd.example.com:8443`.
+	Remote string `yaml:"remote" mapstructure:"remote"`
+
+	// Path to the TLS client certificate used to authenticate with a remote LXD server.
+	ClientCert string `yaml:"client-cert" mapstructure:"client-cert"`
+
+	// Path to the TLS client key used to authenticate with a remote LXD server.
+	ClientKey string `yaml:"client-key" mapstructure:"client-key"`
+
+	// Path to the TLS certificate of the remote LXD server.
+	// If not set, the system CA is used to verify the remote server.
+	ServerCert string `yaml:"server-cert" mapstructure:"server-cert"`
+
+	// LXD project to create Act instances within.
+	Project string `yaml:"project" mapstructure:"project"`
+
+	// Create a dedicated LXD project for each run. The project is
+	// removed after all Act instances of the run are destroyed.
+	ProjectPerRun bool `yaml:"project-per-run" mapstructure:"project-per-run"`
+
+	// Additional configuration of per-run projects, e.g. quota
+	// limits such as `limits.instances` or `limits.memory`.
+	ProjectConfig map[string]string `yaml:"project-config" mapstructure:"project-config"`
+
+	// Profiles to apply to every Act instance.
+	Profiles []string `yaml:"profiles" mapstructure:"profiles"`
+
+	// Simplestreams server to pull unqualified `run-on` images from.
+	ImageServer string `yaml:"image-server" mapstructure:"image-server"`
+
+	// Additional image remotes keyed by name. Images from these remotes
+	// can be referenced in `run-on` as `<remote>:<image>`.
+	Remotes map[string]Remote `yaml:"remotes" mapstructure:"remotes"`
+
+	// Isolated network to attach Act instances to.
+	Network Network `yaml:"network" mapstructure:"network"`
+
+	// Write the Act id of every instance of the run into `/etc/hosts`
+	// of each instance, so that Acts can reach each other by Act id.
+	ActHosts bool `yaml:"act-hosts" mapstructure:"act-hosts"`
+}
+
+// `Network` represents the configuration of the isolated network
+// that is created for each playthrough run.
+type Network struct {
+	// Create a dedicated bridge network for each run
+	// and attach every Act instance to it.
+	Isolated bool `yaml:"isolated" mapstructure:"isolated"`
+
+	// IPv4 address and subnet of the bridge in CIDR notation,
+	// e.g. `10.42.0.1/24`. Defaults to a random unused subnet.
+	Subnet string `yaml:"subnet" mapstructure:"subnet"`
+
+	// DNS domain of the network. Act instances can
+	// reach each other at `<instance>.<domain>`.
+	Domain string `yaml:"domain" mapstructure:"domain"`
+}
+
+// Load LXD provider configuration. Values set in the gambol configuration
+// file are used as defaults for values set in the playthrough `node`.
+// `node` may be nil if the playthrough does not configure the provider.
+func LoadConfig(node *yaml.Node) (config Config, err error) {
+	config = Config{
+		Socket:      defaultSocket,
+		ImageServer: defaultImageServer,
+	}
+	if err := viper.UnmarshalKey("provider.lxd", &config); err != nil {
+		return config, err
+	}
+	if node != nil {
+		if err := node.Decode(&config); err != nil {
+			return config, err
+		}
+	}
+
+	return config, nil
+}
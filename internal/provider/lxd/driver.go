@@ -2,39 +2,114 @@ package lxd
 
 import (
 	"bytes"
-	"errors"
+	"context"
 	"fmt"
 	"io"
+	"os"
 	"slices"
+	"time"
 
 	lxd "github.com/canonical/lxd/client"
 	"github.com/canonical/lxd/shared/api"
-	"github.com/dsnet/golib/memfile"
 	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+
+	"github.com/nuccitheboss/gambol/internal/provider"
+	"github.com/nuccitheboss/gambol/internal/provider/scripts"
 	"github.com/nuccitheboss/gambol/internal/storage"
+	"github.com/nuccitheboss/gambol/internal/tracing"
 )
 
+func init() {
+	provider.Register("lxd", func(node *yaml.Node) (provider.Provider, error) {
+		config, err := LoadConfig(node)
+		if err != nil {
+			return nil, err
+		}
+		driver, err := New(config)
+		if err != nil {
+			return nil, err
+		}
+		return &driver, nil
+	})
+}
+
+// Time to wait for the `lxd-agent` of a virtual machine to start.
+const agentTimeout = 5 * time.Minute
+
 type Driver struct {
 	server lxd.InstanceServer
+	config Config
+
+	// Isolated network of the current run, if any.
+	network string
+
+	// Project of the current run, if any, and the
+	// server connection to the project it was created from.
+	project string
+	base    lxd.InstanceServer
+
+	// Act ids of the instances created by this driver, keyed by instance name.
+	aliases map[string]string
 }
 
-// Establish connection to LXD server.
-//
-// FIXME: Currently just using the Unix socket
-// because that's the easiest. Will get more advanced
-// later once further requirements and use cases are clear.
-func New() (provider Driver, err error) {
-	server, err := lxd.ConnectLXDUnix("/var/snap/lxd/common/lxd/unix.socket", nil)
-	if err != nil {
-		return provider, err
+// Establish connection to LXD server. Connects to the remote
+// LXD server if `remote` is configured, otherwise connects to
+// the local LXD server over the configured Unix socket.
+func New(config Config) (provider Driver, err error) {
+	var server lxd.InstanceServer
+	if config.Remote != "" {
+		args, err := connectionArgs(config)
+		if err != nil {
+			return provider, err
+		}
+		server, err = lxd.ConnectLXD(config.Remote, args)
+		if err != nil {
+			return provider, err
+		}
+	} else {
+		server, err = lxd.ConnectLXDUnix(config.Socket, nil)
+		if err != nil {
+			return provider, err
+		}
+	}
+
+	if config.Project != "" {
+		server = server.UseProject(config.Project)
 	}
 
 	provider.server = server
+	provider.config = config
+	provider.aliases = map[string]string{}
 	return provider, nil
 }
 
-func (p *Driver) CheckIfInstanceExists(id string) (bool, error) {
-	names, err := p.server.GetInstanceNames("container")
+// Assemble TLS connection arguments for a remote LXD server.
+func connectionArgs(config Config) (*lxd.ConnectionArgs, error) {
+	args := &lxd.ConnectionArgs{}
+	for _, f := range []struct {
+		path  string
+		value *string
+	}{
+		{config.ClientCert, &args.TLSClientCert},
+		{config.ClientKey, &args.TLSClientKey},
+		{config.ServerCert, &args.TLSServerCert},
+	} {
+		if f.path == "" {
+			continue
+		}
+		data, err := os.ReadFile(f.path)
+		if err != nil {
+			return nil, err
+		}
+		*f.value = string(data)
+	}
+
+	return args, nil
+}
+
+func (p *Driver) CheckIfInstanceExists(ctx context.Context, id string) (bool, error) {
+	names, err := p.server.GetInstanceNames(api.InstanceTypeAny)
 	if err != nil {
 		return false, err
 	}
@@ -42,7 +117,7 @@ func (p *Driver) CheckIfInstanceExists(id string) (bool, error) {
 	return slices.Contains(names, id), nil
 }
 
-func (p *Driver) CheckIfInstanceActive(id string) (bool, error) {
+func (p *Driver) CheckIfInstanceActive(ctx context.Context, id string) (bool, error) {
 	instance, _, err := p.server.GetInstanceState(id)
 	if err != nil {
 		return false, err
@@ -57,18 +132,25 @@ func (p *Driver) CheckIfInstanceActive(id string) (bool, error) {
 	}
 }
 
-func (p *Driver) CreateInstance(id string, platform string) error {
-	request := api.InstancesPost{
-		Name: id,
-		Source: api.InstanceSource{
-			Type:     "image",
-			Protocol: "simplestreams",
-			Server:   "https://cloud-images.ubuntu.com/releases",
-			Alias:    platform,
-		},
-		Type: "container",
+func (p *Driver) CreateInstance(ctx context.Context, id string, options provider.InstanceOptions) (err error) {
+	ctx, span := tracing.Start(ctx, "lxd.CreateInstance", tracing.InstanceKey.String(id))
+	defer func() { tracing.End(span, err) }()
+
+	if err := p.create(ctx, id, options); err != nil {
+		return err
 	}
-	op, err := p.server.CreateInstance(request)
+	if err := p.start(ctx, id); err != nil {
+		return err
+	}
+	if err := p.waitForInit(ctx, id); err != nil {
+		return err
+	}
+
+	execRequest := api.InstanceExecPost{
+		Command: []string{"mkdir", "-p", "/root/.gambol/input", "/root/.gambol/output"},
+	}
+	execArgs := lxd.InstanceExecArgs{}
+	op, err := p.server.ExecInstance(id, execRequest, &execArgs)
 	if err != nil {
 		return err
 	}
@@ -77,50 +159,131 @@ func (p *Driver) CreateInstance(id string, platform string) error {
 		return err
 	}
 
-	startRequest := api.InstanceStatePut{
-		Action:  "start",
-		Timeout: -1,
+	if options.Act != "" && p.config.ActHosts {
+		p.aliases[id] = options.Act
+		return p.aliasInstance(ctx, id)
 	}
-	op, err = p.server.UpdateInstanceState(id, startRequest, "")
+
+	return nil
+}
+
+// Create the instance from its image.
+func (p *Driver) create(ctx context.Context, id string, options provider.InstanceOptions) (err error) {
+	_, span := tracing.Start(ctx, "lxd.create", tracing.InstanceKey.String(id))
+	defer func() { tracing.End(span, err) }()
+
+	instanceType := api.InstanceTypeContainer
+	if options.Type == provider.InstanceTypeVirtualMachine {
+		instanceType = api.InstanceTypeVM
+	}
+	source, err := p.imageSource(options.Image, instanceType)
 	if err != nil {
 		return err
 	}
-	err = op.Wait()
+	put, err := p.instancePut(options)
+	if err != nil {
+		return err
+	}
+	request := api.InstancesPost{
+		Name:        id,
+		Source:      source,
+		Type:        instanceType,
+		InstancePut: put,
+	}
+	op, err := p.server.CreateInstance(request)
 	if err != nil {
 		return err
 	}
 
-	// Wait for Act instance to finish initializing.
+	return op.Wait()
+}
+
+// Wait for Act instance to finish initializing. Not every
+// image ships with cloud-init, e.g. images from `images:`.
+func (p *Driver) waitForInit(ctx context.Context, id string) (err error) {
+	_, span := tracing.Start(ctx, "lxd.cloud-init", tracing.InstanceKey.String(id))
+	defer func() { tracing.End(span, err) }()
+
 	initRequest := api.InstanceExecPost{
-		Command: []string{"cloud-init", "status", "-w"},
+		Command: []string{"sh", "-c", "if command -v cloud-init > /dev/null; then cloud-init status -w; fi"},
 	}
 	initArgs := lxd.InstanceExecArgs{}
-	op, err = p.server.ExecInstance(id, initRequest, &initArgs)
+	op, err := p.server.ExecInstance(id, initRequest, &initArgs)
 	if err != nil {
 		return err
 	}
-	err = op.Wait()
+
+	return op.Wait()
+}
+
+func (p *Driver) start(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "lxd.start", tracing.InstanceKey.String(id))
+	defer func() { tracing.End(span, err) }()
+
+	startRequest := api.InstanceStatePut{
+		Action:  "start",
+		Timeout: -1,
+	}
+	op, err := p.server.UpdateInstanceState(id, startRequest, "")
 	if err != nil {
 		return err
 	}
 
-	execRequest := api.InstanceExecPost{
-		Command: []string{"mkdir", "-p", "/root/.gambol/input", "/root/.gambol/output"},
+	if err := op.Wait(); err != nil {
+		return err
 	}
-	execArgs := lxd.InstanceExecArgs{}
-	op, err = p.server.ExecInstance(id, execRequest, &execArgs)
+
+	instance, _, err := p.server.GetInstance(id)
 	if err != nil {
 		return err
 	}
-	err = op.Wait()
-	if err != nil {
+	if instance.Type == string(api.InstanceTypeVM) {
+		return p.waitForAgent(ctx, id)
+	}
+
+	return nil
+}
+
+// Virtual machines can only execute commands once the
+// `lxd-agent` is running inside the guest. Wait until the
+// agent reports the running processes of the instance.
+func (p *Driver) waitForAgent(ctx context.Context, id string) (err error) {
+	_, span := tracing.Start(ctx, "lxd.wait-agent", tracing.InstanceKey.String(id))
+	defer func() { tracing.End(span, err) }()
+
+	deadline := time.Now().Add(agentTimeout)
+	for time.Now().Before(deadline) {
+		state, _, err := p.server.GetInstanceState(id)
+		if err != nil {
+			return err
+		}
+		if state.Processes > 0 {
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+
+	return fmt.Errorf("timed out waiting for lxd-agent to start in instance '%s'", id)
+}
+
+func (p *Driver) StartInstance(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "lxd.StartInstance", tracing.InstanceKey.String(id))
+	defer func() { tracing.End(span, err) }()
+
+	if err := p.start(ctx, id); err != nil {
 		return err
 	}
+	if _, exists := p.aliases[id]; exists {
+		return p.aliasInstance(ctx, id)
+	}
 
 	return nil
 }
 
-func (p *Driver) StopInstance(id string) error {
+func (p *Driver) StopInstance(ctx context.Context, id string) (err error) {
+	_, span := tracing.Start(ctx, "lxd.StopInstance", tracing.InstanceKey.String(id))
+	defer func() { tracing.End(span, err) }()
+
 	stopRequest := api.InstanceStatePut{
 		Action:  "stop",
 		Timeout: -1,
@@ -137,44 +300,55 @@ func (p *Driver) StopInstance(id string) error {
 	return nil
 }
 
-func (p *Driver) DestroyInstances(ids []string) error {
+func (p *Driver) DestroyInstances(ctx context.Context, ids []string) (err error) {
+	ctx, span := tracing.Start(ctx, "lxd.DestroyInstances")
+	defer func() { tracing.End(span, err) }()
+
 	for _, id := range ids {
-		active, err := p.CheckIfInstanceActive(id)
+		active, err := p.CheckIfInstanceActive(ctx, id)
 		if err != nil {
 			return nil
 		}
 		if active {
-			if err := p.StopInstance(id); err != nil {
+			if err := p.StopInstance(ctx, id); err != nil {
 				return err
 			}
 		}
-		if _, err := p.server.DeleteInstance(id); err != nil {
+		op, err := p.server.DeleteInstance(id)
+		if err != nil {
+			return err
+		}
+		if err := op.Wait(); err != nil {
 			return err
 		}
+		delete(p.aliases, id)
 	}
 
 	return nil
 }
 
-func (p *Driver) ExecInstance(id string, script string) error {
+func (p *Driver) ExecInstance(ctx context.Context, id string, script string, stdout io.Writer, stderr io.Writer) (err error) {
+	_, span := tracing.Start(ctx, "lxd.ExecInstance", tracing.InstanceKey.String(id))
+	defer func() { tracing.End(span, err) }()
+
 	uploadArgs := lxd.InstanceFileArgs{
 		Content:   bytes.NewReader([]byte(script)),
 		WriteMode: "overwrite",
 		Type:      "file",
 	}
-	err := p.server.CreateInstanceFile(id, "/root/.gambol/run", uploadArgs)
+	err = p.server.CreateInstanceFile(id, "/root/.gambol/run", uploadArgs)
 	if err != nil {
 		return err
 	}
 
-	stdout := memfile.New([]byte(""))
 	execRequest := api.InstanceExecPost{
 		Command:   []string{"bash", "/root/.gambol/run"},
 		WaitForWS: true,
 	}
 	execArgs := lxd.InstanceExecArgs{
-		Stdout: stdout,
-		Stderr: stdout,
+		Stdout:   stdout,
+		Stderr:   stderr,
+		DataDone: make(chan bool),
 	}
 	op, err := p.server.ExecInstance(id, execRequest, &execArgs)
 	if err != nil {
@@ -186,31 +360,28 @@ func (p *Driver) ExecInstance(id string, script string) error {
 		return err
 	}
 
+	// Wait for all output to be streamed before returning.
+	<-execArgs.DataDone
+
 	if returnCode := op.Get().Metadata["return"]; returnCode != float64(0) {
-		fmt.Println("error encountered during scene execution:")
-		fmt.Println(string(stdout.Bytes()))
-		return errors.New("scene failed")
+		code, _ := returnCode.(float64)
+		span.SetAttributes(tracing.ExitCodeKey.Int(int(code)))
+		return &provider.ExitError{Code: int(code)}
 	}
 
 	return nil
 }
 
-var getArtifactScript = `
-UUID=%s
-TARGET=%s
-pushd $(dirname ${TARGET})
-tar -cf /root/.gambol/output/${UUID}.tar $(basename ${TARGET})
-`
-
 // Get Artifact from Act instance.
-func (p *Driver) GetArtifact(id string, artifact storage.Artifact) (out []byte, err error) {
-	uniqueID := uuid.NewString()
-	wrapper := fmt.Sprintf(getArtifactScript, uniqueID, artifact.Path)
-	if err := p.ExecInstance(id, wrapper); err != nil {
+func (p *Driver) GetArtifact(ctx context.Context, id string, artifact storage.Artifact) (out []byte, err error) {
+	ctx, span := tracing.Start(ctx, "lxd.GetArtifact", tracing.InstanceKey.String(id))
+	defer func() { tracing.End(span, err) }()
+
+	target := fmt.Sprintf("/root/.gambol/output/%s.tar", uuid.NewString())
+	if err := scripts.Run(ctx, p, id, scripts.GetArtifact(target, artifact.Path)); err != nil {
 		return nil, err
 	}
 
-	target := fmt.Sprintf("/root/.gambol/output/%s.tar", uniqueID)
 	buf, _, err := p.server.GetInstanceFile(id, target)
 	if err != nil {
 		return nil, err
@@ -224,25 +395,12 @@ func (p *Driver) GetArtifact(id string, artifact storage.Artifact) (out []byte,
 	return out, nil
 }
 
-var putFileArtifactScript = `
-UUID=%s
-TARGET=%s
-OUTPUT=$(mktemp -p /tmp -d)
-tar -xf /root/.gambol/input/${UUID}.tar -C ${OUTPUT}
-mkdir -p $(dirname ${TARGET})
-mv ${OUTPUT}/* ${TARGET}
-`
-var putDirArtifactScript = `
-UUID=%s
-TARGET=%s
-mkdir -p ${TARGET}
-tar -xf /root/.gambol/input/${UUID}.tar -C ${TARGET} --strip-components 1
-`
-
 // Put (upload) Artifact into Act instance.
-func (p *Driver) PutArtifact(id string, artifact storage.Artifact, input []byte) error {
-	uniqueID := uuid.NewString()
-	target := fmt.Sprintf("/root/.gambol/input/%s.tar", uniqueID)
+func (p *Driver) PutArtifact(ctx context.Context, id string, artifact storage.Artifact, input []byte) (err error) {
+	ctx, span := tracing.Start(ctx, "lxd.PutArtifact", tracing.InstanceKey.String(id))
+	defer func() { tracing.End(span, err) }()
+
+	target := fmt.Sprintf("/root/.gambol/input/%s.tar", uuid.NewString())
 	args := lxd.InstanceFileArgs{
 		Content:   bytes.NewReader(input),
 		Type:      "file",
@@ -256,14 +414,7 @@ func (p *Driver) PutArtifact(id string, artifact storage.Artifact, input []byte)
 	if err != nil {
 		return err
 	}
-
-	var wrapper string
-	if !dir {
-		wrapper = fmt.Sprintf(putFileArtifactScript, uniqueID, artifact.Path)
-	} else {
-		wrapper = fmt.Sprintf(putDirArtifactScript, uniqueID, artifact.Path)
-	}
-	if err := p.ExecInstance(id, wrapper); err != nil {
+	if err := scripts.Run(ctx, p, id, scripts.PutArtifact(target, artifact.Path, dir)); err != nil {
 		return err
 	}
 
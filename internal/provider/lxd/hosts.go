@@ -0,0 +1,119 @@
+package lxd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+
+	"github.com/nuccitheboss/gambol/internal/provider/scripts"
+	"github.com/nuccitheboss/gambol/internal/tracing"
+)
+
+// Time to wait for an Act instance to be assigned an IPv4 address.
+const addressTimeout = 30 * time.Second
+
+var hostsScript = `
+sed -i '/^# BEGIN gambol$/,/^# END gambol$/d' /etc/hosts
+cat >> /etc/hosts << 'HOSTS'
+# BEGIN gambol
+%s
+# END gambol
+HOSTS
+`
+
+// Make a started Act instance reachable by its Act id from every running
+// instance of the run. Instances that are not assigned an IPv4 address
+// are left out rather than failing the Act.
+func (p *Driver) aliasInstance(ctx context.Context, id string) error {
+	if err := p.waitForAddress(ctx, id); err != nil {
+		slog.Warn("instance cannot be reached by its Act id", "instance", id, "error", err)
+	}
+
+	return p.syncHosts(ctx)
+}
+
+// Act instances are named with a run-scoped prefix, so Act ids are not
+// resolvable as host names by the LXD DNS server. Write the Act id of
+// every running instance of the run into `/etc/hosts` of each instance
+// so that Acts can reach each other by Act id.
+func (p *Driver) syncHosts(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "lxd.sync-hosts")
+	defer func() { tracing.End(span, err) }()
+
+	names := make([]string, 0, len(p.aliases))
+	for name := range p.aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var entries, running []string
+	for _, name := range names {
+		state, _, err := p.server.GetInstanceState(name)
+		if err != nil {
+			return err
+		}
+		if state.StatusCode != api.Running {
+			continue
+		}
+		running = append(running, name)
+		if address := ipv4Address(state); address != "" {
+			entries = append(entries, fmt.Sprintf("%s %s", address, p.aliases[name]))
+		}
+	}
+
+	script := fmt.Sprintf(hostsScript, strings.Join(entries, "\n"))
+	for _, name := range running {
+		if err := scripts.Run(ctx, p, name, script); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Wait until the instance has been assigned an IPv4 address.
+func (p *Driver) waitForAddress(ctx context.Context, id string) (err error) {
+	_, span := tracing.Start(ctx, "lxd.wait-address", tracing.InstanceKey.String(id))
+	defer func() { tracing.End(span, err) }()
+
+	deadline := time.Now().Add(addressTimeout)
+	for time.Now().Before(deadline) {
+		state, _, err := p.server.GetInstanceState(id)
+		if err != nil {
+			return err
+		}
+		if ipv4Address(state) != "" {
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+
+	return fmt.Errorf("timed out waiting for instance '%s' to be assigned an address", id)
+}
+
+// Get the first global IPv4 address of the instance.
+func ipv4Address(state *api.InstanceState) string {
+	names := make([]string, 0, len(state.Network))
+	for name := range state.Network {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == "lo" {
+			continue
+		}
+		for _, address := range state.Network[name].Addresses {
+			if address.Family == "inet" && address.Scope == "global" {
+				return address.Address
+			}
+		}
+	}
+
+	return ""
+}
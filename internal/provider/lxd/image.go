@@ -0,0 +1,126 @@
+package lxd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/canonical/lxd/shared/api"
+)
+
+// Name of the remote that refers to images stored on the LXD server itself.
+const localRemote = "local"
+
+// `Remote` is an image server that `run-on` images can be pulled from.
+type Remote struct {
+	// URL of the image server.
+	URL string `yaml:"url" mapstructure:"url"`
+
+	// Protocol used by the image server. Either `simplestreams` (default) or `lxd`.
+	Protocol string `yaml:"protocol" mapstructure:"protocol"`
+}
+
+// Image remotes that are always available. Can be overridden
+// by remotes with the same name in the provider configuration.
+var defaultRemotes = map[string]Remote{
+	"ubuntu":       {URL: "https://cloud-images.ubuntu.com/releases", Protocol: "simplestreams"},
+	"ubuntu-daily": {URL: "https://cloud-images.ubuntu.com/daily", Protocol: "simplestreams"},
+	"images":       {URL: "https://images.lxd.canonical.com", Protocol: "simplestreams"},
+}
+
+// Resolve a `run-on` image reference to an instance source.
+//
+// Images can be qualified with a remote, e.g. `images:alpine/3.20` or
+// `ubuntu-daily:oracular`. Two remotes are special: `local:<alias or
+// fingerprint>` references an image already on the LXD server, and
+// `file:<path>` imports an image tarball from the host. Unqualified
+// images are looked up on the LXD server first, and are otherwise
+// pulled from the configured `image-server`.
+func (p *Driver) imageSource(image string, instanceType api.InstanceType) (api.InstanceSource, error) {
+	remote, name, qualified := strings.Cut(image, ":")
+	if !qualified {
+		name = image
+		local, err := p.localImageSource(name)
+		if err != nil {
+			return api.InstanceSource{}, err
+		}
+		if local != nil {
+			return *local, nil
+		}
+
+		return api.InstanceSource{
+			Type:     "image",
+			Protocol: "simplestreams",
+			Server:   p.config.ImageServer,
+			Alias:    name,
+		}, nil
+	}
+
+	if remote == fileRemote {
+		return p.importImageSource(name, instanceType)
+	}
+
+	if remote == localRemote {
+		local, err := p.localImageSource(name)
+		if err != nil {
+			return api.InstanceSource{}, err
+		}
+		if local == nil {
+			return api.InstanceSource{}, fmt.Errorf("image '%s' not found on LXD server", name)
+		}
+		return *local, nil
+	}
+
+	r, exists := p.config.Remotes[remote]
+	if !exists {
+		r, exists = defaultRemotes[remote]
+	}
+	if !exists {
+		return api.InstanceSource{}, fmt.Errorf("unknown image remote '%s' for image '%s'", remote, image)
+	}
+	protocol := r.Protocol
+	if protocol == "" {
+		protocol = "simplestreams"
+	}
+
+	return api.InstanceSource{
+		Type:     "image",
+		Protocol: protocol,
+		Server:   r.URL,
+		Alias:    name,
+	}, nil
+}
+
+// Look up an image by alias or fingerprint on the LXD server.
+// Returns nil if the image is not found.
+func (p *Driver) localImageSource(name string) (*api.InstanceSource, error) {
+	if _, _, err := p.server.GetImageAlias(name); err == nil {
+		return &api.InstanceSource{Type: "image", Alias: name}, nil
+	} else if !api.StatusErrorCheck(err, 404) {
+		return nil, err
+	}
+
+	if !isFingerprint(name) {
+		return nil, nil
+	}
+	if image, _, err := p.server.GetImage(name); err == nil {
+		return &api.InstanceSource{Type: "image", Fingerprint: image.Fingerprint}, nil
+	} else if !api.StatusErrorCheck(err, 404) {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// Check if name could be a (partial) image fingerprint.
+func isFingerprint(name string) bool {
+	if len(name) < 12 {
+		return false
+	}
+	for _, c := range name {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+
+	return true
+}
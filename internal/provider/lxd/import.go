@@ -0,0 +1,100 @@
+package lxd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	lxd "github.com/canonical/lxd/client"
+	"github.com/canonical/lxd/shared/api"
+)
+
+// Name of the remote that refers to image files on the host.
+const fileRemote = "file"
+
+// Import a local image file into the LXD server, and resolve it to
+// an instance source. `files` is either the path to a unified image
+// tarball, or `<metadata>,<rootfs>` for a split image.
+//
+// Images are identified by their fingerprint, so an image file is only
+// imported once. Later runs reuse the image already stored by LXD.
+func (p *Driver) importImageSource(files string, instanceType api.InstanceType) (api.InstanceSource, error) {
+	meta, rootfs, split := strings.Cut(files, ",")
+	paths := []string{meta}
+	if split {
+		paths = append(paths, rootfs)
+	}
+
+	fingerprint, err := imageFingerprint(paths)
+	if err != nil {
+		return api.InstanceSource{}, err
+	}
+
+	if _, _, err := p.server.GetImage(fingerprint); err == nil {
+		slog.Debug("reusing imported image", "image", files, "fingerprint", fingerprint)
+		return api.InstanceSource{Type: "image", Fingerprint: fingerprint}, nil
+	} else if !api.StatusErrorCheck(err, 404) {
+		return api.InstanceSource{}, err
+	}
+
+	slog.Debug("importing image", "image", files, "fingerprint", fingerprint)
+	metaFile, err := os.Open(meta)
+	if err != nil {
+		return api.InstanceSource{}, err
+	}
+	defer metaFile.Close()
+
+	args := &lxd.ImageCreateArgs{
+		MetaFile: metaFile,
+		MetaName: filepath.Base(meta),
+		Type:     string(instanceType),
+	}
+	if split {
+		rootfsFile, err := os.Open(rootfs)
+		if err != nil {
+			return api.InstanceSource{}, err
+		}
+		defer rootfsFile.Close()
+		args.RootfsFile = rootfsFile
+		args.RootfsName = filepath.Base(rootfs)
+	}
+
+	op, err := p.server.CreateImage(api.ImagesPost{Filename: filepath.Base(meta)}, args)
+	if err != nil {
+		return api.InstanceSource{}, err
+	}
+	if err := op.Wait(); err != nil {
+		return api.InstanceSource{}, err
+	}
+
+	imported, ok := op.Get().Metadata["fingerprint"].(string)
+	if !ok {
+		return api.InstanceSource{}, fmt.Errorf("failed to import image '%s'", files)
+	}
+
+	return api.InstanceSource{Type: "image", Fingerprint: imported}, nil
+}
+
+// Compute the LXD fingerprint of an image. The fingerprint is the
+// SHA-256 hash of the metadata tarball followed by the rootfs, if any.
+func imageFingerprint(paths []string) (string, error) {
+	hash := sha256.New()
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(hash, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
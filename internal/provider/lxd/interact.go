@@ -0,0 +1,95 @@
+package lxd
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	lxd "github.com/canonical/lxd/client"
+	"github.com/canonical/lxd/shared/api"
+	"github.com/gorilla/websocket"
+	"golang.org/x/term"
+)
+
+// Run a command within an Act instance attached to the terminal of gambol.
+func (p *Driver) Interact(id string, command []string, tty bool) (int, error) {
+	request := api.InstanceExecPost{
+		Command:     command,
+		WaitForWS:   true,
+		Interactive: tty,
+		Cwd:         "/root",
+		Environment: map[string]string{"HOME": "/root", "TERM": os.Getenv("TERM")},
+	}
+	args := lxd.InstanceExecArgs{
+		Stdin:    os.Stdin,
+		Stdout:   os.Stdout,
+		Stderr:   os.Stderr,
+		DataDone: make(chan bool),
+	}
+
+	fd := int(os.Stdin.Fd())
+	if tty {
+		if !term.IsTerminal(fd) {
+			return 0, errors.New("standard input is not a terminal")
+		}
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			return 0, err
+		}
+		request.Width, request.Height = width, height
+
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return 0, err
+		}
+		defer term.Restore(fd, state)
+
+		done := make(chan struct{})
+		defer close(done)
+		args.Control = resizeHandler(fd, done)
+	}
+
+	op, err := p.server.ExecInstance(id, request, &args)
+	if err != nil {
+		return 0, err
+	}
+	if err := op.Wait(); err != nil {
+		return 0, err
+	}
+	<-args.DataDone
+
+	code, _ := op.Get().Metadata["return"].(float64)
+	return int(code), nil
+}
+
+// Forward size changes of the terminal to the pseudo-terminal of the
+// command until done is closed once the command has finished.
+func resizeHandler(fd int, done <-chan struct{}) func(conn *websocket.Conn) {
+	return func(conn *websocket.Conn) {
+		resize := make(chan os.Signal, 1)
+		signal.Notify(resize, syscall.SIGWINCH)
+		defer signal.Stop(resize)
+		defer conn.Close()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-resize:
+			}
+
+			width, height, err := term.GetSize(fd)
+			if err != nil {
+				continue
+			}
+			if err := conn.WriteJSON(api.InstanceExecControl{
+				Command: "window-resize",
+				Args:    map[string]string{"width": strconv.Itoa(width), "height": strconv.Itoa(height)},
+			}); err != nil {
+				return
+			}
+		}
+	}
+}
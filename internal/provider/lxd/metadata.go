@@ -0,0 +1,132 @@
+package lxd
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/canonical/lxd/shared/api"
+
+	"github.com/nuccitheboss/gambol/internal/provider"
+)
+
+// Instance configuration keys that gambol metadata is stored under.
+const (
+	metadataRun         = "user.gambol.run"
+	metadataPlaythrough = "user.gambol.playthrough"
+	metadataAct         = "user.gambol.act"
+	metadataOwner       = "user.gambol.owner"
+	metadataCreated     = "user.gambol.created"
+)
+
+// Convert gambol metadata to instance configuration.
+func metadataConfig(metadata provider.Metadata) map[string]string {
+	if metadata.Run == "" {
+		return nil
+	}
+
+	return map[string]string{
+		metadataRun:         metadata.Run,
+		metadataPlaythrough: metadata.Playthrough,
+		metadataAct:         metadata.Act,
+		metadataOwner:       metadata.Owner,
+		metadataCreated:     metadata.Created.UTC().Format(time.RFC3339),
+	}
+}
+
+// List all instances tagged with gambol metadata across all projects.
+func (p *Driver) ListInstances() ([]provider.Instance, error) {
+	instances, err := p.gambolInstances()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]provider.Instance, 0, len(instances))
+	for _, instance := range instances {
+		created, _ := time.Parse(time.RFC3339, instance.Config[metadataCreated])
+		result = append(result, provider.Instance{
+			Name:   instance.Name,
+			Status: instance.Status,
+			Metadata: provider.Metadata{
+				Run:         instance.Config[metadataRun],
+				Playthrough: instance.Config[metadataPlaythrough],
+				Act:         instance.Config[metadataAct],
+				Owner:       instance.Config[metadataOwner],
+				Created:     created,
+			},
+		})
+	}
+
+	return result, nil
+}
+
+// Delete all instances of a run, along with the isolated
+// network and project of the run if they still exist.
+func (p *Driver) CollectRun(run string) error {
+	instances, err := p.gambolInstances()
+	if err != nil {
+		return err
+	}
+
+	for _, instance := range instances {
+		if instance.Config[metadataRun] != run {
+			continue
+		}
+
+		server := p.server.UseProject(instance.Project)
+		if instance.StatusCode != api.Stopped {
+			op, err := server.UpdateInstanceState(instance.Name, api.InstanceStatePut{
+				Action:  "stop",
+				Timeout: -1,
+				Force:   true,
+			}, "")
+			if err != nil {
+				return err
+			}
+			if err := op.Wait(); err != nil {
+				return err
+			}
+		}
+
+		slog.Debug("deleting instance", "instance", instance.Name, "project", instance.Project)
+		op, err := server.DeleteInstance(instance.Name)
+		if err != nil {
+			return err
+		}
+		if err := op.Wait(); err != nil {
+			return err
+		}
+	}
+
+	name := provider.RunPrefix(run)
+	if _, _, err := p.server.GetNetwork(name); err == nil {
+		slog.Debug("deleting isolated network", "network", name)
+		if err := p.server.DeleteNetwork(name); err != nil {
+			return err
+		}
+	}
+	if _, _, err := p.server.GetProject(name); err == nil {
+		slog.Debug("deleting project", "project", name)
+		if err := p.server.DeleteProject(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get all instances tagged with a gambol run id.
+func (p *Driver) gambolInstances() ([]api.Instance, error) {
+	instances, err := p.server.GetInstancesAllProjects(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []api.Instance
+	for _, instance := range instances {
+		if instance.Config[metadataRun] != "" {
+			result = append(result, instance)
+		}
+	}
+
+	return result, nil
+}
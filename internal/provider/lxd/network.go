@@ -0,0 +1,71 @@
+package lxd
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/canonical/lxd/shared/api"
+)
+
+// Create the isolated network of the run.
+func (p *Driver) createNetwork(name string, run string) error {
+	config := map[string]string{
+		"ipv4.address": "auto",
+		"ipv4.nat":     "true",
+		"ipv6.address": "none",
+	}
+	if p.config.Network.Subnet != "" {
+		config["ipv4.address"] = p.config.Network.Subnet
+	}
+	if p.config.Network.Domain != "" {
+		config["dns.domain"] = p.config.Network.Domain
+	}
+
+	slog.Debug("creating isolated network", "network", name)
+	if err := p.server.CreateNetwork(api.NetworksPost{
+		Name:       name,
+		Type:       "bridge",
+		NetworkPut: api.NetworkPut{Config: config, Description: "gambol run " + run},
+	}); err != nil {
+		return err
+	}
+
+	p.network = name
+	return nil
+}
+
+// Delete the isolated network of the run if one was created.
+func (p *Driver) deleteNetwork() error {
+	if p.network == "" {
+		return nil
+	}
+
+	slog.Debug("deleting isolated network", "network", p.network)
+	if err := p.server.DeleteNetwork(p.network); err != nil {
+		return err
+	}
+
+	p.network = ""
+	return nil
+}
+
+// Attach the NIC of an Act instance to the isolated network of the run.
+func (p *Driver) networkDevice(address string) (map[string]string, error) {
+	if p.network == "" {
+		if address != "" {
+			return nil, fmt.Errorf("static address '%s' requires an isolated network", address)
+		}
+		return nil, nil
+	}
+
+	device := map[string]string{
+		"type":    "nic",
+		"name":    "eth0",
+		"network": p.network,
+	}
+	if address != "" {
+		device["ipv4.address"] = address
+	}
+
+	return device, nil
+}
@@ -0,0 +1,52 @@
+package lxd
+
+import (
+	"log/slog"
+	"maps"
+
+	"github.com/canonical/lxd/shared/api"
+)
+
+// Create a dedicated project for the run and switch to it.
+//
+// The project shares images and profiles with the project it was created
+// from, so that images are only downloaded once and Act instances receive
+// the same root disk and network devices as they would without a project.
+func (p *Driver) createProject(name string, run string) error {
+	config := map[string]string{
+		"features.images":          "false",
+		"features.profiles":        "false",
+		"features.storage.volumes": "false",
+		"features.networks":        "false",
+	}
+	maps.Copy(config, p.config.ProjectConfig)
+
+	slog.Debug("creating project", "project", name)
+	if err := p.server.CreateProject(api.ProjectsPost{
+		Name:       name,
+		ProjectPut: api.ProjectPut{Config: config, Description: "gambol run " + run},
+	}); err != nil {
+		return err
+	}
+
+	p.base = p.server
+	p.server = p.server.UseProject(name)
+	p.project = name
+	return nil
+}
+
+// Switch back from the project of the run and delete it.
+func (p *Driver) deleteProject() error {
+	if p.project == "" {
+		return nil
+	}
+
+	slog.Debug("deleting project", "project", p.project)
+	p.server = p.base
+	if err := p.server.DeleteProject(p.project); err != nil {
+		return err
+	}
+
+	p.project = ""
+	return nil
+}
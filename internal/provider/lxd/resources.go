@@ -0,0 +1,86 @@
+package lxd
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/canonical/lxd/shared/api"
+
+	"github.com/nuccitheboss/gambol/internal/provider"
+)
+
+// Assemble the configuration, devices, and profiles of a new Act instance.
+// Resource limits and the isolated network of the run are applied on top
+// of the free-form `config` and `devices`.
+func (p *Driver) instancePut(options provider.InstanceOptions) (api.InstancePut, error) {
+	put := api.InstancePut{
+		Profiles: p.config.Profiles,
+		Config:   map[string]string{},
+		Devices:  map[string]map[string]string{},
+	}
+	if len(options.Profiles) > 0 {
+		put.Profiles = options.Profiles
+	}
+	maps.Copy(put.Config, options.Config)
+	maps.Copy(put.Config, metadataConfig(options.Metadata))
+	for name, device := range options.Devices {
+		put.Devices[name] = maps.Clone(device)
+	}
+
+	if _, exists := put.Devices["eth0"]; !exists {
+		nic, err := p.networkDevice(options.Address)
+		if err != nil {
+			return put, err
+		}
+		if nic != nil {
+			put.Devices["eth0"] = nic
+		}
+	}
+
+	if options.Resources.CPU != "" {
+		put.Config["limits.cpu"] = options.Resources.CPU
+	}
+	if options.Resources.Memory != "" {
+		put.Config["limits.memory"] = options.Resources.Memory
+	}
+	if options.Resources.Disk != "" {
+		root, exists := put.Devices["root"]
+		if !exists {
+			pool, err := p.rootPool(put.Profiles)
+			if err != nil {
+				return put, err
+			}
+			root = map[string]string{"type": "disk", "path": "/", "pool": pool}
+			put.Devices["root"] = root
+		}
+		root["size"] = options.Resources.Disk
+	}
+
+	return put, nil
+}
+
+// Find the storage pool of the root disk device provided by the given
+// profiles. Later profiles take precedence like they do in LXD.
+func (p *Driver) rootPool(profiles []string) (string, error) {
+	if profiles == nil {
+		profiles = []string{"default"}
+	}
+
+	var pool string
+	for _, name := range profiles {
+		profile, _, err := p.server.GetProfile(name)
+		if err != nil {
+			return "", err
+		}
+		for _, device := range profile.Devices {
+			if device["type"] == "disk" && device["path"] == "/" {
+				pool = device["pool"]
+			}
+		}
+	}
+	if pool == "" {
+		return "", fmt.Errorf("no root disk device found in profiles %v to resize", profiles)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,53 @@
+package lxd
+
+import (
+	"errors"
+
+	"github.com/nuccitheboss/gambol/internal/provider"
+)
+
+// Set up the project and isolated network of the run if configured.
+func (p *Driver) Setup(run string) error {
+	// Network names are used as interface names on the host,
+	// so they are limited to 15 characters.
+	name := provider.RunPrefix(run)
+
+	if p.config.ProjectPerRun {
+		if err := p.createProject(name, run); err != nil {
+			return err
+		}
+	}
+	if p.config.Network.Isolated {
+		if err := p.createNetwork(name, run); err != nil {
+			// Don't leave the project of the run behind.
+			return errors.Join(err, p.deleteProject())
+		}
+	}
+
+	return nil
+}
+
+// Switch to the project and isolated network of an existing run if configured.
+func (p *Driver) Attach(run string) error {
+	name := provider.RunPrefix(run)
+
+	if p.config.ProjectPerRun {
+		p.base = p.server
+		p.server = p.server.UseProject(name)
+		p.project = name
+	}
+	if p.config.Network.Isolated {
+		p.network = name
+	}
+
+	return nil
+}
+
+// Delete the isolated network and project of the run if created.
+func (p *Driver) Teardown() error {
+	if err := p.deleteNetwork(); err != nil {
+		return err
+	}
+
+	return p.deleteProject()
+}
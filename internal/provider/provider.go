@@ -0,0 +1,235 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nuccitheboss/gambol/internal/storage"
+)
+
+// `Provider` provides the isolated instances that Acts
+// and their complementing Scenes are executed within.
+type Provider interface {
+	// Check if an instance with the given id exists.
+	CheckIfInstanceExists(ctx context.Context, id string) (bool, error)
+
+	// Check if the instance with the given id is running.
+	CheckIfInstanceActive(ctx context.Context, id string) (bool, error)
+
+	// Create and start a new instance.
+	CreateInstance(ctx context.Context, id string, options InstanceOptions) error
+
+	// Start a stopped instance.
+	StartInstance(ctx context.Context, id string) error
+
+	// Stop a running instance.
+	StopInstance(ctx context.Context, id string) error
+
+	// Stop and delete instances.
+	DestroyInstances(ctx context.Context, ids []string) error
+
+	// Execute a script within an instance, streaming its standard output
+	// and error as it runs. Returns an `*ExitError` if the script fails.
+	ExecInstance(ctx context.Context, id string, script string, stdout io.Writer, stderr io.Writer) error
+
+	// Get (download) an artifact from an instance as a tarball.
+	GetArtifact(ctx context.Context, id string, artifact storage.Artifact) ([]byte, error)
+
+	// Put (upload) a tarred artifact into an instance.
+	PutArtifact(ctx context.Context, id string, artifact storage.Artifact, input []byte) error
+}
+
+// `ExitError` is returned by `ExecInstance` if a script exits non-zero.
+type ExitError struct {
+	// Exit code of the script.
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("script exited with code %d", e.Code)
+}
+
+// `Buffer` captures output written to it from multiple goroutines,
+// e.g. both the standard output and error of a script.
+type Buffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *Buffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *Buffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// `Lifecycle` is implemented by providers that need to set up run-scoped
+// resources before any Act instance is created, and clean them up after
+// all Act instances of the run have been destroyed.
+type Lifecycle interface {
+	// Set up resources for the run with the given unique id.
+	Setup(run string) error
+
+	// Clean up the resources of the run.
+	Teardown() error
+
+	// Use the resources of a run that were set up by
+	// another gambol process, e.g. to inspect its instances.
+	Attach(run string) error
+}
+
+// Unique prefix for the names of resources created for a run.
+// Kept short since some providers limit the length of names.
+func RunPrefix(run string) string {
+	return "gambol-" + strings.ReplaceAll(run, "-", "")[:8]
+}
+
+// Supported Act instance types.
+const (
+	InstanceTypeContainer      = "container"
+	InstanceTypeVirtualMachine = "virtual-machine"
+)
+
+// `InstanceOptions` are the options used to create an Act instance.
+type InstanceOptions struct {
+	// Platform image to create the instance from.
+	Image string
+
+	// Type of the instance. Either `container` or `virtual-machine`.
+	// Providers that cannot tell the difference may ignore it.
+	Type string
+
+	// Resource limits of the instance.
+	Resources Resources
+
+	// Provider-specific instance configuration.
+	Config map[string]string
+
+	// Provider-specific devices to attach to the instance.
+	Devices map[string]map[string]string
+
+	// Profiles to apply to the instance. Overrides the
+	// default profiles of the provider if set.
+	Profiles []string
+
+	// Static IPv4 address of the instance.
+	Address string
+
+	// Id of the Act the instance is created for. Providers can use
+	// it as an alias so Act instances can reach each other by Act id.
+	Act string
+
+	// Metadata to tag the instance with.
+	Metadata Metadata
+}
+
+// `Metadata` identifies an instance as created by gambol.
+type Metadata struct {
+	// Unique id of the run that created the instance.
+	Run string
+
+	// Name of the playthrough the run is executing.
+	Playthrough string
+
+	// Id of the Act the instance was created for.
+	Act string
+
+	// Id of the gambol installation that created the instance.
+	Owner string
+
+	// Time the instance was created.
+	Created time.Time
+}
+
+// `Instance` is an instance created by gambol.
+type Instance struct {
+	// Name of the instance.
+	Name string
+
+	// Status of the instance as reported by the provider, e.g. `Running`.
+	Status string
+
+	// Metadata the instance was tagged with.
+	Metadata Metadata
+}
+
+// `Collector` is implemented by providers that can find instances
+// created by gambol, and clean up after runs that did not tear down
+// their instances, e.g. because gambol crashed.
+type Collector interface {
+	// List all instances tagged with gambol metadata.
+	ListInstances() ([]Instance, error)
+
+	// Delete all instances and run-scoped resources of a run.
+	CollectRun(run string) error
+}
+
+// `Interactive` is implemented by providers that can attach the terminal
+// of gambol to a command running within an Act instance, e.g. to inspect
+// the instances of a kept run.
+type Interactive interface {
+	// Run a command within an instance with the standard streams of gambol
+	// attached to it, and return its exit code. If tty is set, the command
+	// runs within a pseudo-terminal.
+	Interact(id string, command []string, tty bool) (int, error)
+}
+
+// `Resources` are the resource limits of an Act instance.
+type Resources struct {
+	// Number of CPUs, or a CPU range such as `0-3`.
+	CPU string `yaml:"cpu,omitempty"`
+
+	// Memory limit, e.g. `16GiB`.
+	Memory string `yaml:"memory,omitempty"`
+
+	// Size of the root disk, e.g. `20GiB`.
+	Disk string `yaml:"disk,omitempty"`
+}
+
+// `Factory` creates a new provider from the provider configuration
+// in the playthrough. `config` is nil if the provider is not configured.
+type Factory func(config *yaml.Node) (Provider, error)
+
+var registry = map[string]Factory{}
+
+// Register a provider factory under the given name. The name
+// is the key used under the `provider` mapping of a playthrough.
+func Register(name string, factory Factory) {
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("provider '%s' is already registered", name))
+	}
+	registry[name] = factory
+}
+
+// Create a new provider using the factory registered under the given name.
+func New(name string, config *yaml.Node) (Provider, error) {
+	factory, exists := registry[name]
+	if !exists {
+		return nil, fmt.Errorf("unsupported provider '%s', expected one of: %v", name, Names())
+	}
+
+	return factory(config)
+}
+
+// Names of all registered providers.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
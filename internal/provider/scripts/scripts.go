@@ -0,0 +1,63 @@
+// Package scripts implements the helper scripts that providers
+// execute within Act instances, e.g. to move artifacts in and out
+// of instances as tar archives.
+package scripts
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nuccitheboss/gambol/internal/provider"
+)
+
+// Execute a helper script within an instance. The output
+// of the script is only included in the error if it fails.
+func Run(ctx context.Context, p provider.Provider, id string, script string) error {
+	var output provider.Buffer
+	if err := p.ExecInstance(ctx, id, script, &output, &output); err != nil {
+		return fmt.Errorf("%w: %s", err, output.String())
+	}
+
+	return nil
+}
+
+var getArtifactScript = `
+OUTPUT=%s
+TARGET=%s
+pushd $(dirname ${TARGET})
+tar -cf ${OUTPUT} $(basename ${TARGET})
+`
+
+// Script to archive the artifact at target within an instance
+// into the tar archive at output.
+func GetArtifact(output string, target string) string {
+	return fmt.Sprintf(getArtifactScript, output, target)
+}
+
+var putFileArtifactScript = `
+INPUT=%s
+TARGET=%s
+OUTPUT=$(mktemp -p /tmp -d)
+tar -xf ${INPUT} -C ${OUTPUT}
+mkdir -p $(dirname ${TARGET})
+mv ${OUTPUT}/* ${TARGET}
+rm -f ${INPUT}
+`
+var putDirArtifactScript = `
+INPUT=%s
+TARGET=%s
+mkdir -p ${TARGET}
+tar -xf ${INPUT} -C ${TARGET} --strip-components 1
+rm -f ${INPUT}
+`
+
+// Script to extract the tar archive at input within an instance to
+// the artifact at target. A directory is extracted into target
+// without its top-level directory. The archive is removed afterwards.
+func PutArtifact(input string, target string, dir bool) string {
+	if dir {
+		return fmt.Sprintf(putDirArtifactScript, input, target)
+	}
+
+	return fmt.Sprintf(putFileArtifactScript, input, target)
+}
@@ -0,0 +1,485 @@
+// Package ssh implements an Act instance provider that targets
+// pre-existing machines over SSH.
+//
+// Machines are declared in a static inventory rather than created.
+// An Act is mapped to an inventory host either by `run-on` naming
+// the host, or by the Act id matching the host name. Scenes are
+// executed over an SSH session, and artifacts are moved via SFTP.
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/sync/singleflight"
+	"gopkg.in/yaml.v3"
+
+	"github.com/nuccitheboss/gambol/internal/provider"
+	"github.com/nuccitheboss/gambol/internal/provider/scripts"
+	"github.com/nuccitheboss/gambol/internal/storage"
+)
+
+func init() {
+	provider.Register("ssh", func(node *yaml.Node) (provider.Provider, error) {
+		var config Config
+		if node != nil {
+			if err := node.Decode(&config); err != nil {
+				return nil, err
+			}
+		}
+		return New(config)
+	})
+}
+
+// `Config` represents the configuration of the SSH provider.
+type Config struct {
+	// Static inventory of machines keyed by host name.
+	Inventory map[string]Host `yaml:"inventory"`
+
+	// Path to the known hosts file used to verify host keys.
+	// Defaults to `~/.ssh/known_hosts`.
+	KnownHosts string `yaml:"known-hosts"`
+
+	// Skip host key verification. Only use for throwaway machines.
+	Insecure bool `yaml:"insecure-skip-host-key-check"`
+}
+
+// `Host` is a machine in the SSH provider inventory.
+type Host struct {
+	// Address of the machine.
+	Host string `yaml:"host"`
+
+	// Port of the SSH server. Defaults to 22.
+	Port int `yaml:"port"`
+
+	// User to log in as. Defaults to `root`.
+	User string `yaml:"user"`
+
+	// Path to the private key to authenticate with. If not set,
+	// the running SSH agent is used to authenticate instead.
+	Key string `yaml:"key"`
+
+	// Execute Scenes with `sudo` if logging in as a non-root user.
+	Sudo bool `yaml:"sudo"`
+}
+
+// How long to wait for an inventory host to accept a connection
+// and complete the SSH and SFTP handshakes.
+const dialTimeout = 30 * time.Second
+
+// `conn` is an established connection to an inventory host.
+type conn struct {
+	client *ssh.Client
+	sftp   *sftp.Client
+
+	// Home directory of the logged in user.
+	home string
+}
+
+type Driver struct {
+	config   Config
+	callback ssh.HostKeyCallback
+
+	mu    sync.Mutex
+	acts  map[string]string
+	conns map[string]*conn
+
+	// Connection attempts in flight, keyed by host name.
+	dials singleflight.Group
+
+	// Connection to the running SSH agent, shared by all hosts
+	// authenticating with it.
+	agentConn net.Conn
+	agent     agent.ExtendedAgent
+}
+
+func New(config Config) (*Driver, error) {
+	if len(config.Inventory) == 0 {
+		return nil, errors.New("ssh provider requires at least one host in `inventory`")
+	}
+	for name, host := range config.Inventory {
+		if host.Host == "" {
+			return nil, fmt.Errorf("inventory host '%s' is missing `host`", name)
+		}
+		if host.Port == 0 {
+			host.Port = 22
+		}
+		if host.User == "" {
+			host.User = "root"
+		}
+		host.Key = expandHome(host.Key)
+		config.Inventory[name] = host
+	}
+
+	var callback ssh.HostKeyCallback
+	if config.Insecure {
+		callback = ssh.InsecureIgnoreHostKey()
+	} else {
+		knownHosts := expandHome(config.KnownHosts)
+		if knownHosts == "" {
+			knownHosts = expandHome("~/.ssh/known_hosts")
+		}
+		cb, err := knownhosts.New(knownHosts)
+		if err != nil {
+			return nil, err
+		}
+		callback = cb
+	}
+
+	return &Driver{
+		config:   config,
+		callback: callback,
+		acts:     map[string]string{},
+		conns:    map[string]*conn{},
+	}, nil
+}
+
+// Check if an inventory host, or an Act mapped to an inventory host, exists.
+func (p *Driver) CheckIfInstanceExists(ctx context.Context, id string) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, exists := p.config.Inventory[id]; exists {
+		return true, nil
+	}
+	_, exists := p.acts[id]
+	return exists, nil
+}
+
+// Check if the inventory host is reachable.
+func (p *Driver) CheckIfInstanceActive(ctx context.Context, id string) (bool, error) {
+	if _, err := p.connect(ctx, id); err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Map the Act instance to an inventory host. The Act runs on the host
+// named by `run-on` if there is one, otherwise on the host with the same
+// name as the Act. Pre-existing machines cannot be created, so all other
+// instance options are ignored.
+func (p *Driver) CreateInstance(ctx context.Context, id string, options provider.InstanceOptions) error {
+	p.mu.Lock()
+	var name string
+	for _, candidate := range []string{options.Image, options.Act} {
+		if _, exists := p.config.Inventory[candidate]; exists {
+			name = candidate
+			break
+		}
+	}
+	if name == "" {
+		p.mu.Unlock()
+		return fmt.Errorf("no host in ssh inventory for Act '%s' to run on", options.Act)
+	}
+	p.acts[id] = name
+	p.mu.Unlock()
+
+	c, err := p.connect(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range []string{".gambol/input", ".gambol/output"} {
+		if err := c.sftp.MkdirAll(path.Join(c.home, dir)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Pre-existing machines are not managed by gambol, so this is a no-op.
+func (p *Driver) StartInstance(ctx context.Context, id string) error {
+	return nil
+}
+
+// Pre-existing machines are not managed by gambol, so this is a no-op.
+func (p *Driver) StopInstance(ctx context.Context, id string) error {
+	return nil
+}
+
+// Close connections to the inventory hosts mapped to the given Acts.
+// The machines themselves are left running.
+func (p *Driver) DestroyInstances(ctx context.Context, ids []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, id := range ids {
+		name := p.resolve(id)
+		delete(p.acts, id)
+		if c, exists := p.conns[name]; exists {
+			c.sftp.Close()
+			c.client.Close()
+			delete(p.conns, name)
+		}
+	}
+
+	return nil
+}
+
+func (p *Driver) ExecInstance(ctx context.Context, id string, script string, stdout io.Writer, stderr io.Writer) error {
+	c, err := p.connect(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	run := path.Join(c.home, ".gambol", "run")
+	if err := p.upload(c, run, bytes.NewReader([]byte(script))); err != nil {
+		return err
+	}
+
+	session, err := c.client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	session.Stdout = stdout
+	session.Stderr = stderr
+	if err := session.Run(p.command(id, "bash "+run)); err != nil {
+		var exitErr *ssh.ExitError
+		if !errors.As(err, &exitErr) {
+			return err
+		}
+		return &provider.ExitError{Code: exitErr.ExitStatus()}
+	}
+
+	return nil
+}
+
+// Get Artifact from Act instance.
+func (p *Driver) GetArtifact(ctx context.Context, id string, artifact storage.Artifact) ([]byte, error) {
+	c, err := p.connect(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	target := path.Join(c.home, ".gambol", "output", uuid.NewString()+".tar")
+	if err := scripts.Run(ctx, p, id, scripts.GetArtifact(target, artifact.Path)); err != nil {
+		return nil, err
+	}
+
+	fin, err := c.sftp.Open(target)
+	if err != nil {
+		return nil, err
+	}
+	defer fin.Close()
+	defer c.sftp.Remove(target)
+
+	return io.ReadAll(fin)
+}
+
+// Put (upload) Artifact into Act instance.
+func (p *Driver) PutArtifact(ctx context.Context, id string, artifact storage.Artifact, input []byte) error {
+	c, err := p.connect(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	target := path.Join(c.home, ".gambol", "input", uuid.NewString()+".tar")
+	if err := p.upload(c, target, bytes.NewReader(input)); err != nil {
+		return err
+	}
+
+	dir, err := artifact.IsDir(input)
+	if err != nil {
+		return err
+	}
+
+	return scripts.Run(ctx, p, id, scripts.PutArtifact(target, artifact.Path, dir))
+}
+
+// Connect to the inventory host mapped to the given id.
+// Connections are reused across calls.
+func (p *Driver) connect(ctx context.Context, id string) (*conn, error) {
+	p.mu.Lock()
+	name := p.resolve(id)
+	c, exists := p.conns[name]
+	host, known := p.config.Inventory[name]
+	p.mu.Unlock()
+	if exists {
+		return c, nil
+	}
+	if !known {
+		return nil, fmt.Errorf("host '%s' not found in ssh inventory", id)
+	}
+
+	// Dial without holding the lock so that an unreachable host does
+	// not block the Acts running on other hosts. Acts connecting to
+	// the same host at once share a single connection attempt.
+	dial := p.dials.DoChan(name, func() (any, error) {
+		p.mu.Lock()
+		c, exists := p.conns[name]
+		p.mu.Unlock()
+		if exists {
+			return c, nil
+		}
+
+		c, err := p.dial(host)
+		if err != nil {
+			return nil, err
+		}
+		p.mu.Lock()
+		p.conns[name] = c
+		p.mu.Unlock()
+		return c, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-dial:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.(*conn), nil
+	}
+}
+
+// Dial the inventory host and open an SFTP session on it.
+func (p *Driver) dial(host Host) (*conn, error) {
+	auth, err := p.authMethod(host)
+	if err != nil {
+		return nil, err
+	}
+	config := &ssh.ClientConfig{
+		User:            host.User,
+		Auth:            []ssh.AuthMethod{auth},
+		HostKeyCallback: p.callback,
+		Timeout:         dialTimeout,
+	}
+
+	addr := net.JoinHostPort(host.Host, strconv.Itoa(host.Port))
+	netConn, err := net.DialTimeout("tcp", addr, config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	// `ClientConfig.Timeout` only bounds establishing the TCP
+	// connection, so bound the handshakes with a deadline too.
+	if err := netConn.SetDeadline(time.Now().Add(config.Timeout)); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, addr, config)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	client := ssh.NewClient(sshConn, chans, reqs)
+	sftpClient, err := sftp.NewClient(client)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	home, err := sftpClient.Getwd()
+	if err == nil {
+		err = netConn.SetDeadline(time.Time{})
+	}
+	if err != nil {
+		sftpClient.Close()
+		client.Close()
+		return nil, err
+	}
+
+	return &conn{client: client, sftp: sftpClient, home: home}, nil
+}
+
+// Upload content to the given path on the inventory host.
+func (p *Driver) upload(c *conn, target string, content io.Reader) error {
+	fout, err := c.sftp.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY)
+	if err != nil {
+		return err
+	}
+	defer fout.Close()
+
+	_, err = io.Copy(fout, content)
+	return err
+}
+
+// Wrap command with `sudo` if configured for the inventory host.
+func (p *Driver) command(id string, command string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if host := p.config.Inventory[p.resolve(id)]; host.Sudo {
+		return "sudo --non-interactive " + command
+	}
+
+	return command
+}
+
+// Resolve an Act id to the name of its inventory host.
+func (p *Driver) resolve(id string) string {
+	if name, exists := p.acts[id]; exists {
+		return name
+	}
+
+	return id
+}
+
+// Get method to authenticate with the inventory host.
+func (p *Driver) authMethod(host Host) (ssh.AuthMethod, error) {
+	if host.Key != "" {
+		key, err := os.ReadFile(host.Key)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return ssh.PublicKeys(signer), nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.agent == nil {
+		socket := os.Getenv("SSH_AUTH_SOCK")
+		if socket == "" {
+			return nil, fmt.Errorf("no `key` set for host '%s' and no ssh agent is running", host.Host)
+		}
+		agentConn, err := net.Dial("unix", socket)
+		if err != nil {
+			return nil, err
+		}
+		p.agentConn = agentConn
+		p.agent = agent.NewClient(agentConn)
+	}
+
+	return ssh.PublicKeysCallback(p.agent.Signers), nil
+}
+
+// Expand a leading `~` in path to the home directory of the current user.
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+
+	return filepath.Join(home, strings.TrimPrefix(p, "~"))
+}
@@ -0,0 +1,30 @@
+package ssh
+
+// Pre-existing machines need no resources set up for a run.
+func (p *Driver) Setup(run string) error {
+	return nil
+}
+
+// Pre-existing machines need no resources set up for a run.
+func (p *Driver) Attach(run string) error {
+	return nil
+}
+
+// Close the connections to the inventory hosts and the SSH agent
+// that are left open, e.g. by Act instances that failed to be created.
+func (p *Driver) Teardown() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for name, c := range p.conns {
+		c.sftp.Close()
+		c.client.Close()
+		delete(p.conns, name)
+	}
+	if p.agentConn != nil {
+		p.agentConn.Close()
+		p.agentConn, p.agent = nil, nil
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+
+	gambol "github.com/nuccitheboss/gambol/internal/common"
+)
+
+// Write every event of a playthrough run to w as a line of JSON.
+func JSONEvents(w io.Writer) gambol.EventHandler {
+	var mu sync.Mutex
+	encoder := json.NewEncoder(w)
+	return func(event gambol.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err := encoder.Encode(event); err != nil {
+			slog.Error("failed to write event", "error", err)
+		}
+	}
+}
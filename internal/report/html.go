@@ -0,0 +1,224 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	gambol "github.com/nuccitheboss/gambol/internal/common"
+)
+
+//go:embed report.html.tmpl
+var htmlTemplate string
+
+// Dimensions of the Act graph in pixels.
+const (
+	graphNodeWidth  = 180
+	graphNodeHeight = 44
+	graphColumnGap  = 90
+	graphRowGap     = 24
+	graphMargin     = 10
+)
+
+// `htmlReport` is the data rendered into the HTML report.
+type htmlReport struct {
+	*gambol.PlayResult
+
+	// Act graph of the playthrough.
+	Graph htmlGraph
+
+	// Rows of the timeline of Acts and Scenes.
+	Timeline []htmlBar
+}
+
+// `htmlGraph` is the laid out graph of Acts. An Act depends on another
+// Act if it runs on the instance of, or pushes artifacts pulled by, the
+// other Act.
+type htmlGraph struct {
+	Width      int
+	Height     int
+	NodeWidth  int
+	NodeHeight int
+	Nodes      []htmlNode
+	Edges      []htmlEdge
+}
+
+type htmlNode struct {
+	Id     string
+	Name   string
+	Status string
+	X, Y   int
+}
+
+type htmlEdge struct {
+	Label          string
+	X1, Y1, X2, Y2 int
+}
+
+// `htmlBar` is a row of the timeline. Offset and width are
+// percentages of the duration of the playthrough.
+type htmlBar struct {
+	Label  string
+	Scene  bool
+	Status string
+	Offset float64
+	Width  float64
+	Title  string
+}
+
+// Write the result of a playthrough to a file as a self-contained HTML page.
+func WriteHTML(file string, result *gambol.PlayResult) error {
+	tmpl, err := template.New("report").Funcs(template.FuncMap{
+		"duration": formatDuration,
+		"size":     formatSize,
+	}).Parse(htmlTemplate)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	report := htmlReport{
+		PlayResult: result,
+		Graph:      graph(result),
+		Timeline:   timeline(result),
+	}
+	if err := tmpl.Execute(f, report); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// Lay out the Acts of the playthrough in columns, so that
+// every Act is placed to the right of the Acts it depends on.
+func graph(result *gambol.PlayResult) htmlGraph {
+	// The dependencies are only known to the playthrough
+	// itself, not to the results of Acts that were skipped.
+	var play gambol.Play
+	if err := yaml.Unmarshal([]byte(result.Playthrough), &play); err != nil {
+		play = gambol.Play{}
+	}
+	outputs := map[string]string{}
+	edges := map[[2]string][]string{}
+	for _, act := range play.Acts {
+		for _, artifact := range act.Option.Input {
+			if from, ok := outputs[artifact.Key]; ok && artifact.Key != "" {
+				edges[[2]string{from, act.Id}] = append(edges[[2]string{from, act.Id}], artifact.Key)
+			}
+		}
+		for _, artifact := range act.Option.Output {
+			if artifact.Key != "" {
+				outputs[artifact.Key] = act.Id
+			}
+		}
+	}
+
+	g := htmlGraph{NodeWidth: graphNodeWidth, NodeHeight: graphNodeHeight}
+	column := map[string]int{}
+	rows := map[int]int{}
+	index := map[string]int{}
+	for _, act := range result.Acts {
+		if _, exists := index[act.Id]; exists {
+			continue
+		}
+		if slices.ContainsFunc(result.Acts, func(other gambol.ActResult) bool { return other.Id == act.RunOn }) {
+			key := [2]string{act.RunOn, act.Id}
+			edges[key] = append([]string{"run-on"}, edges[key]...)
+		}
+		for key := range edges {
+			if key[1] == act.Id {
+				column[act.Id] = max(column[act.Id], column[key[0]]+1)
+			}
+		}
+
+		c := column[act.Id]
+		index[act.Id] = len(g.Nodes)
+		g.Nodes = append(g.Nodes, htmlNode{
+			Id:     act.Id,
+			Name:   act.Name,
+			Status: act.Status,
+			X:      graphMargin + c*(graphNodeWidth+graphColumnGap),
+			Y:      graphMargin + rows[c]*(graphNodeHeight+graphRowGap),
+		})
+		rows[c]++
+		g.Width = max(g.Width, graphMargin*2+(c+1)*graphNodeWidth+c*graphColumnGap)
+		g.Height = max(g.Height, graphMargin*2+rows[c]*graphNodeHeight+(rows[c]-1)*graphRowGap)
+	}
+
+	for _, act := range result.Acts {
+		for _, from := range result.Acts {
+			labels, ok := edges[[2]string{from.Id, act.Id}]
+			if !ok {
+				continue
+			}
+			a, b := g.Nodes[index[from.Id]], g.Nodes[index[act.Id]]
+			g.Edges = append(g.Edges, htmlEdge{
+				Label: strings.Join(labels, ", "),
+				X1:    a.X + graphNodeWidth,
+				Y1:    a.Y + graphNodeHeight/2,
+				X2:    b.X,
+				Y2:    b.Y + graphNodeHeight/2,
+			})
+		}
+	}
+
+	return g
+}
+
+// Place Acts and their Scenes on the timeline of the playthrough.
+func timeline(result *gambol.PlayResult) []htmlBar {
+	total := result.Duration
+	if total <= 0 {
+		total = time.Millisecond
+	}
+	bar := func(label string, scene bool, status string, started time.Time, duration time.Duration) htmlBar {
+		b := htmlBar{Label: label, Scene: scene, Status: status}
+		if status == gambol.StatusSkipped || started.IsZero() {
+			b.Title = "skipped"
+			return b
+		}
+		b.Offset = 100 * float64(started.Sub(result.Started)) / float64(total)
+		b.Width = max(100*float64(duration)/float64(total), 0.2)
+		b.Title = fmt.Sprintf("%s, %s", status, formatDuration(duration))
+		return b
+	}
+
+	var bars []htmlBar
+	for _, act := range result.Acts {
+		bars = append(bars, bar(act.Name, false, act.Status, act.Started, act.Duration))
+		for _, scene := range act.Scenes {
+			bars = append(bars, bar(scene.Name, true, scene.Status, scene.Started, scene.Duration))
+		}
+	}
+
+	return bars
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+func formatSize(size int) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := unit, 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,178 @@
+// Package report renders the results of playthrough runs for humans and CI.
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	gambol "github.com/nuccitheboss/gambol/internal/common"
+)
+
+// `junitTestSuites` is the root element of a JUnit XML report.
+// The playthrough maps to the root element, and every Act maps
+// to a test suite with a test case for each of its Scenes.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Id        string          `xml:"id,attr"`
+	Hostname  string          `xml:"hostname,attr,omitempty"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut *junitOutput  `xml:"system-out,omitempty"`
+	SystemErr *junitOutput  `xml:"system-err,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",cdata"`
+}
+
+type junitOutput struct {
+	Text string `xml:",cdata"`
+}
+
+// Write the result of a playthrough to a file as a JUnit XML report.
+func WriteJUnit(file string, result *gambol.PlayResult) error {
+	data, err := xml.MarshalIndent(junit(result), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, append([]byte(xml.Header), append(data, '\n')...), 0644)
+}
+
+func junit(result *gambol.PlayResult) junitTestSuites {
+	report := junitTestSuites{Name: result.Name, Time: seconds(result.Duration)}
+	for _, act := range result.Acts {
+		suite := junitTestSuite{
+			Name:     act.Name,
+			Id:       act.Id,
+			Hostname: act.Instance,
+			Time:     seconds(act.Duration),
+		}
+		if !act.Started.IsZero() {
+			suite.Timestamp = act.Started.UTC().Format(time.RFC3339)
+		}
+
+		classname := act.Id
+		if result.Name != "" {
+			classname = result.Name + "." + act.Id
+		}
+		sceneFailed := false
+		for _, scene := range act.Scenes {
+			testcase := junitTestCase{
+				Name:      scene.Name,
+				Classname: classname,
+				Time:      seconds(scene.Duration),
+				SystemOut: junitOutputOf(scene.Stdout),
+				SystemErr: junitOutputOf(scene.Stderr),
+			}
+			switch scene.Status {
+			case gambol.StatusFailed:
+				sceneFailed = true
+				testcase.Failure = &junitMessage{
+					Message: scene.Error,
+					Type:    fmt.Sprintf("exit code %d", scene.ExitCode),
+					Text:    output(scene),
+				}
+				suite.Failures++
+			case gambol.StatusSkipped:
+				testcase.Skipped = &junitMessage{Message: scene.SkippedReason}
+				suite.Skipped++
+			}
+			suite.Cases = append(suite.Cases, testcase)
+		}
+
+		// Acts can also fail outside of their Scenes, e.g. if their
+		// instance cannot be created. Report these as errors of the Act.
+		if act.Status == gambol.StatusFailed && !sceneFailed {
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      act.Name,
+				Classname: classname,
+				Time:      seconds(act.Duration),
+				Error:     &junitMessage{Message: act.Error},
+			})
+			suite.Errors++
+		}
+
+		suite.Tests = len(suite.Cases)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, suite)
+	}
+
+	return report
+}
+
+// Combined captured output of a Scene, sanitised for XML.
+func output(scene gambol.SceneResult) string {
+	return xmlText(rawOutput(scene))
+}
+
+// Combined captured output of a Scene as is.
+func rawOutput(scene gambol.SceneResult) string {
+	var b strings.Builder
+	b.WriteString(scene.Stdout)
+	if scene.Stderr != "" {
+		if b.Len() > 0 && !strings.HasSuffix(scene.Stdout, "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString(scene.Stderr)
+	}
+
+	return b.String()
+}
+
+func junitOutputOf(text string) *junitOutput {
+	if text == "" {
+		return nil
+	}
+
+	return &junitOutput{Text: xmlText(text)}
+}
+
+// Drop characters that are not allowed in XML documents,
+// e.g. the escape sequences of colored Scene output.
+func xmlText(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || (r >= 0x20 && r != 0xFFFE && r != 0xFFFF) {
+			return r
+		}
+		return -1
+	}, text)
+}
+
+// Format a duration in seconds as expected by JUnit.
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
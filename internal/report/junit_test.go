@@ -0,0 +1,100 @@
+package report
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gambol "github.com/nuccitheboss/gambol/internal/common"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	result := &gambol.PlayResult{
+		Name:     "test",
+		Status:   gambol.StatusFailed,
+		Duration: 3 * time.Second,
+		Acts: []gambol.ActResult{
+			{
+				Id:       "build",
+				Name:     "Build",
+				Instance: "gambol-1a2b3c4d-build",
+				Status:   gambol.StatusFailed,
+				Duration: 2 * time.Second,
+				Scenes: []gambol.SceneResult{
+					{Name: "Compile", Status: gambol.StatusPassed, Duration: time.Second},
+					{
+						Name:     "Test",
+						Status:   gambol.StatusFailed,
+						ExitCode: 2,
+						Error:    "exit code 2",
+						Stdout:   "ok\n",
+						Stderr:   "FAIL \x1b[31mbad\x1b[0m\n",
+					},
+					{Name: "Package", Status: gambol.StatusSkipped, SkippedReason: "a previous Scene failed"},
+				},
+			},
+			{
+				Id:            "deploy",
+				Name:          "Deploy",
+				Status:        gambol.StatusSkipped,
+				SkippedReason: "a previous Act failed",
+				Scenes: []gambol.SceneResult{
+					{Name: "Install", Status: gambol.StatusSkipped, SkippedReason: "a previous Act failed"},
+				},
+			},
+			{
+				Id:     "provision",
+				Name:   "Provision",
+				Status: gambol.StatusFailed,
+				Error:  "image not found",
+				Scenes: []gambol.SceneResult{},
+			},
+		},
+	}
+
+	file := filepath.Join(t.TempDir(), "report.xml")
+	if err := WriteJUnit(file, result); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report junitTestSuites
+	if err := xml.Unmarshal(data, &report); err != nil {
+		t.Fatalf("report is not valid XML: %v", err)
+	}
+
+	if report.Tests != 5 || report.Failures != 1 || report.Errors != 1 || report.Skipped != 2 {
+		t.Errorf("report has %d tests, %d failures, %d errors, and %d skipped, want 5, 1, 1, and 2",
+			report.Tests, report.Failures, report.Errors, report.Skipped)
+	}
+	if len(report.Suites) != 3 {
+		t.Fatalf("report has %d test suites, want 3", len(report.Suites))
+	}
+
+	build := report.Suites[0]
+	if build.Hostname != "gambol-1a2b3c4d-build" || build.Time != "2.000" {
+		t.Errorf("suite of Act 'build' has hostname %q and time %q", build.Hostname, build.Time)
+	}
+	failure := build.Cases[1].Failure
+	if failure == nil {
+		t.Fatal("failed Scene has no failure")
+	}
+	if failure.Type != "exit code 2" {
+		t.Errorf("failure has type %q, want %q", failure.Type, "exit code 2")
+	}
+	if want := "ok\nFAIL [31mbad[0m\n"; failure.Text != want {
+		t.Errorf("failure has output %q, want %q", failure.Text, want)
+	}
+	if skipped := build.Cases[2].Skipped; skipped == nil || skipped.Message != "a previous Scene failed" {
+		t.Errorf("skipped Scene has skipped element %+v", skipped)
+	}
+
+	provision := report.Suites[2]
+	if len(provision.Cases) != 1 || provision.Cases[0].Error == nil || provision.Cases[0].Error.Message != "image not found" {
+		t.Errorf("Act failing outside of its Scenes is reported as %+v", provision.Cases)
+	}
+}
@@ -0,0 +1,211 @@
+package report
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	gambol "github.com/nuccitheboss/gambol/internal/common"
+)
+
+// `logWriter` writes the events of a run into a log directory:
+//
+//	<dir>/<run-id>/run.log                 run started and finished
+//	<dir>/<run-id>/cleanup.log             cleanup of instances
+//	<dir>/<run-id>/<act>/events.log        instance and artifact events
+//	<dir>/<run-id>/<act>/<nn>-<scene>.log  output of each Scene
+//
+// Act ids are turned into file names the same way as Scene names, so
+// that they cannot point outside of the log directory of the run.
+type logWriter struct {
+	mu sync.Mutex
+
+	// Directory to create the log directories of runs within.
+	dir string
+
+	// Log directory of the run.
+	run string
+
+	// Number of Scenes started per Act.
+	scenes map[string]int
+
+	// Log directories of Acts keyed by Act id, and the Act ids
+	// keyed by log directory.
+	acts map[string]string
+	dirs map[string]string
+
+	// Log file of the Scene being executed.
+	scene *os.File
+
+	// First error encountered. No more logs are written afterwards.
+	err error
+}
+
+// Write the events of a run into a log directory under dir,
+// with a log file for every Scene of the run.
+func Logs(dir string) gambol.EventHandler {
+	w := &logWriter{dir: dir, scenes: map[string]int{}, acts: map[string]string{}, dirs: map[string]string{}}
+	return w.handle
+}
+
+// Log directory of a run under dir.
+func LogDir(dir string, run string) string {
+	return filepath.Join(dir, run)
+}
+
+func (w *logWriter) handle(event gambol.Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return
+	}
+	if err := w.write(event); err != nil {
+		slog.Error("failed to write run logs", "error", err)
+		w.err = err
+		w.closeScene()
+	}
+}
+
+func (w *logWriter) write(event gambol.Event) error {
+	switch event.Type {
+	case gambol.EventRunStarted:
+		w.run = LogDir(w.dir, event.Run)
+		if err := os.MkdirAll(w.run, 0755); err != nil {
+			return err
+		}
+		return w.appendLine("run.log", event)
+	case gambol.EventRunFinished:
+		return w.appendLine("run.log", event)
+	case gambol.EventCleanupStarted, gambol.EventCleanupFinished:
+		return w.appendLine("cleanup.log", event)
+	case gambol.EventSceneStarted:
+		w.scenes[event.Act]++
+		name := fmt.Sprintf("%02d-%s.log", w.scenes[event.Act], slug(event.Scene))
+		f, err := os.Create(filepath.Join(w.run, w.actDir(event.Act), name))
+		if err != nil {
+			return err
+		}
+		w.scene = f
+		_, err = fmt.Fprintf(f, "=== Scene %q of Act %q started at %s\n", event.Scene, event.Act, timestamp(event.Time))
+		return err
+	case gambol.EventSceneOutput:
+		if w.scene == nil {
+			return nil
+		}
+		_, err := fmt.Fprintln(w.scene, event.Line)
+		return err
+	case gambol.EventSceneFinished:
+		if w.scene == nil {
+			return nil
+		}
+		_, err := fmt.Fprintf(w.scene, "=== Scene %q %s with exit code %d after %s\n",
+			event.Scene, event.Status, *event.ExitCode, formatDuration(event.Duration))
+		if event.Error != "" && err == nil {
+			_, err = fmt.Fprintf(w.scene, "=== %s\n", event.Error)
+		}
+		if cerr := w.closeScene(); err == nil {
+			err = cerr
+		}
+		return err
+	case gambol.EventActStarted:
+		if err := os.MkdirAll(filepath.Join(w.run, w.actDir(event.Act)), 0755); err != nil {
+			return err
+		}
+		return w.appendLine(filepath.Join(w.actDir(event.Act), "events.log"), event)
+	default:
+		if event.Act == "" {
+			return w.appendLine("run.log", event)
+		}
+		return w.appendLine(filepath.Join(w.actDir(event.Act), "events.log"), event)
+	}
+}
+
+// Log directory of an Act within the log directory of the run. Act ids
+// that turn into the same file name are told apart by a number suffix.
+func (w *logWriter) actDir(act string) string {
+	if dir, exists := w.acts[act]; exists {
+		return dir
+	}
+
+	dir := slug(act)
+	for n := 2; w.dirs[dir] != ""; n++ {
+		dir = fmt.Sprintf("%s-%d", slug(act), n)
+	}
+	w.acts[act] = dir
+	w.dirs[dir] = act
+	return dir
+}
+
+// Append an event as a line of `key=value` pairs to a log file of the run.
+func (w *logWriter) appendLine(name string, event gambol.Event) error {
+	f, err := os.OpenFile(filepath.Join(w.run, name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fields := []string{timestamp(event.Time), event.Type}
+	add := func(key string, value string) {
+		if value != "" {
+			fields = append(fields, fmt.Sprintf("%s=%q", key, value))
+		}
+	}
+	add("playthrough", event.Playthrough)
+	add("instance", event.Instance)
+	add("artifact", event.Artifact)
+	if event.Size > 0 {
+		add("size", fmt.Sprint(event.Size))
+	}
+	add("status", event.Status)
+	if event.Duration > 0 {
+		add("duration", formatDuration(event.Duration))
+	}
+	add("error", event.Error)
+
+	_, err = fmt.Fprintln(f, strings.Join(fields, " "))
+	return err
+}
+
+func (w *logWriter) closeScene() error {
+	if w.scene == nil {
+		return nil
+	}
+	err := w.scene.Close()
+	w.scene = nil
+	return err
+}
+
+// Turn a Scene name or Act id into a file name, e.g.
+// `Start controller service` into `start-controller-service`.
+func slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+
+	s := strings.TrimSuffix(b.String(), "-")
+	if runes := []rune(s); len(runes) > 64 {
+		s = strings.TrimSuffix(string(runes[:64]), "-")
+	}
+	if s == "" {
+		s = "scene"
+	}
+	return s
+}
+
+func timestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
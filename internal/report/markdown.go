@@ -0,0 +1,126 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	gambol "github.com/nuccitheboss/gambol/internal/common"
+)
+
+// Number of lines of output shown for each failed Scene.
+const markdownOutputLines = 50
+
+// Append the result of a playthrough to a file as a markdown summary,
+// e.g. to `$GITHUB_STEP_SUMMARY` of a GitHub Actions job.
+func AppendMarkdown(file string, result *gambol.PlayResult) error {
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := WriteMarkdown(f, result); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// Write the result of a playthrough as a markdown summary.
+func WriteMarkdown(w io.Writer, result *gambol.PlayResult) error {
+	var b strings.Builder
+	icon := map[string]string{
+		gambol.StatusPassed:  "✅",
+		gambol.StatusFailed:  "❌",
+		gambol.StatusSkipped: "⏭️",
+	}
+
+	counts := map[string]int{}
+	for _, act := range result.Acts {
+		for _, scene := range act.Scenes {
+			counts[scene.Status]++
+		}
+	}
+	fmt.Fprintf(&b, "## %s Playthrough %s %s\n\n", icon[result.Status], markdownCode(result.Name), result.Status)
+	fmt.Fprintf(&b, "Run `%s`: %d passed, %d failed, %d skipped Scenes in %s.\n\n",
+		result.Run, counts[gambol.StatusPassed], counts[gambol.StatusFailed],
+		counts[gambol.StatusSkipped], formatDuration(result.Duration))
+
+	b.WriteString("| Act | Scene | Status | Duration |\n")
+	b.WriteString("| --- | --- | --- | --- |\n")
+	for _, act := range result.Acts {
+		fmt.Fprintf(&b, "| **%s** | | %s %s | %s |\n",
+			markdownCell(act.Name), icon[act.Status], act.Status, markdownDuration(act.Status, act.Duration))
+		for _, scene := range act.Scenes {
+			fmt.Fprintf(&b, "| | %s | %s %s | %s |\n",
+				markdownCell(scene.Name), icon[scene.Status], scene.Status, markdownDuration(scene.Status, scene.Duration))
+		}
+	}
+
+	for _, act := range result.Acts {
+		if act.Status != gambol.StatusFailed {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", markdownCell(act.Name))
+		fence := markdownFence(act.Error)
+		fmt.Fprintf(&b, "%s\n%s\n%s\n", fence, act.Error, fence)
+		for _, scene := range act.Scenes {
+			if scene.Status != gambol.StatusFailed {
+				continue
+			}
+			fmt.Fprintf(&b, "\n<details open><summary>Output of Scene %s</summary>\n\n", markdownCode(scene.Name))
+			text := tail(rawOutput(scene), markdownOutputLines)
+			if text != "" && !strings.HasSuffix(text, "\n") {
+				text += "\n"
+			}
+			fence := markdownFence(text)
+			fmt.Fprintf(&b, "%s\n%s%s\n\n</details>\n", fence, text, fence)
+		}
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Get the last n lines of a text.
+func tail(text string, n int) string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines[max(len(lines)-n, 0):], "")
+}
+
+// Get a code fence that is longer than any run of backticks in text,
+// so that the text cannot close it.
+func markdownFence(text string) string {
+	longest, run := 0, 0
+	for _, c := range text {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(longest+1, 3))
+}
+
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
+
+func markdownCode(s string) string {
+	return "`" + strings.ReplaceAll(markdownCell(s), "`", "'") + "`"
+}
+
+func markdownDuration(status string, d time.Duration) string {
+	if status == gambol.StatusSkipped {
+		return "-"
+	}
+	return formatDuration(d)
+}
@@ -15,14 +15,14 @@ import (
 // and Outputs will be cached locally after all steps have completed successfully.
 type Artifact struct {
 	// Key for storing or retrieving artifact from cache.
-	Key string `yaml:"key"`
+	Key string `yaml:"key,omitempty"`
 
 	// Path to retrieve or dump artifact on host.
-	HostPath string `yaml:"host-path"`
+	HostPath string `yaml:"host-path,omitempty"`
 
 	// Path to push or pull artifact to or from
 	// in Act instance.
-	Path string `yaml:"path"`
+	Path string `yaml:"path,omitempty"`
 }
 
 // Get unique name of the artifact. If both a `key and `host-path` are
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"path"
+	"slices"
 
 	"github.com/spf13/viper"
 	"go.etcd.io/bbolt"
@@ -22,9 +23,7 @@ type Cache struct {
 
 func NewCache(name string) (c Cache, err error) {
 	c.name = []byte(name)
-	storagePath := viper.GetString("storage")
-	c.artifactDB = path.Join(storagePath, "artifact.db")
-	c.instanceDB = path.Join(storagePath, "instance.db")
+	c.setPaths()
 	err = c.init()
 	if err != nil {
 		return c, err
@@ -32,6 +31,14 @@ func NewCache(name string) (c Cache, err error) {
 	return c, nil
 }
 
+// Open the existing playthrough cache with the given name,
+// e.g. to inspect the instances of a run from another process.
+func OpenCache(name string) Cache {
+	c := Cache{name: []byte(name)}
+	c.setPaths()
+	return c
+}
+
 func (c *Cache) PutArtifact(key string, artifact []byte) error {
 	db, err := c.openArtifactDB()
 	if err != nil {
@@ -82,7 +89,8 @@ func (c *Cache) GetArtifact(key string) ([]byte, error) {
 	return artifact, nil
 }
 
-func (c *Cache) PutInstanceId(id string) error {
+// Map an Act id to the name of the instance the Act runs within.
+func (c *Cache) PutInstance(act string, name string) error {
 	db, err := c.openInstanceDB()
 	if err != nil {
 		return err
@@ -95,7 +103,7 @@ func (c *Cache) PutInstanceId(id string) error {
 			return errors.New("failed to open instance cache")
 		}
 
-		return b.Put([]byte(id), nil)
+		return b.Put([]byte(act), []byte(name))
 	}); err != nil {
 		return err
 	}
@@ -103,22 +111,67 @@ func (c *Cache) PutInstanceId(id string) error {
 	return nil
 }
 
+// Get the name of the instance an Act of this playthrough runs within.
+// Returns an empty string if the Act has no instance.
+func (c *Cache) GetInstance(act string) (string, error) {
+	db, err := c.openInstanceDB()
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	var name string
+	if err := db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(c.name)
+		if b == nil {
+			return errors.New("failed to open instance cache")
+		}
+		name = string(b.Get([]byte(act)))
+
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// Get the names of all instances created for this playthrough.
 func (c *Cache) GetInstanceIds() ([]string, error) {
+	instances, err := c.GetInstances()
+	if err != nil {
+		return nil, err
+	}
+
+	// Acts can run within the instance of a previous
+	// Act, so multiple Acts can map to the same instance.
+	var names []string
+	for _, name := range instances {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// Get the mapping of Act ids to instance names for this playthrough.
+func (c *Cache) GetInstances() (map[string]string, error) {
 	db, err := c.openInstanceDB()
 	if err != nil {
 		return nil, err
 	}
 	defer db.Close()
 
-	var names []string
+	instances := map[string]string{}
 	if err := db.View(func(tx *bbolt.Tx) error {
 		b := tx.Bucket(c.name)
 		if b == nil {
 			return errors.New("failed to open instance cache")
 		}
 
-		if err := b.ForEach(func(k, _ []byte) error {
-			names = append(names, string(k))
+		if err := b.ForEach(func(k, v []byte) error {
+			instances[string(k)] = string(v)
 			return nil
 		}); err != nil {
 			return err
@@ -129,7 +182,7 @@ func (c *Cache) GetInstanceIds() ([]string, error) {
 		return nil, err
 	}
 
-	return names, nil
+	return instances, nil
 }
 
 // Flush out caches after successful completion of playthrough.
@@ -161,6 +214,66 @@ func (c *Cache) Flush() error {
 	return nil
 }
 
+// Get the names of all playthrough cache buckets, including buckets
+// left behind by runs that did not complete successfully.
+func GetCacheNames() ([]string, error) {
+	var names []string
+	c := Cache{}
+	c.setPaths()
+	for _, open := range []func() (*bbolt.DB, error){c.openArtifactDB, c.openInstanceDB} {
+		db, err := open()
+		if err != nil {
+			return nil, err
+		}
+		err = db.View(func(tx *bbolt.Tx) error {
+			return tx.ForEach(func(name []byte, _ *bbolt.Bucket) error {
+				if !slices.Contains(names, string(name)) {
+					names = append(names, string(name))
+				}
+				return nil
+			})
+		})
+		db.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return names, nil
+}
+
+// Delete the playthrough cache buckets with the given name.
+func DeleteCache(name string) error {
+	c := Cache{name: []byte(name)}
+	c.setPaths()
+	for _, open := range []func() (*bbolt.DB, error){c.openArtifactDB, c.openInstanceDB} {
+		db, err := open()
+		if err != nil {
+			return err
+		}
+		err = db.Update(func(tx *bbolt.Tx) error {
+			err := tx.DeleteBucket(c.name)
+			if errors.Is(err, bbolt.ErrBucketNotFound) {
+				return nil
+			}
+			return err
+		})
+		db.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Set paths to the artifact and instance database.
+func (c *Cache) setPaths() {
+	storagePath := viper.GetString("storage")
+	c.artifactDB = path.Join(storagePath, "artifact.db")
+	c.instanceDB = path.Join(storagePath, "instance.db")
+}
+
 // Initialize artifact and instance database.
 func (c *Cache) init() error {
 	adb, err := c.openArtifactDB()
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"path"
+
+	"github.com/spf13/viper"
+	"go.etcd.io/bbolt"
+)
+
+// Name of the bucket that the history of runs is stored within.
+var historyBucket = []byte("history")
+
+// Record a finished run in the history of runs. The history
+// outlives the run state and playthrough cache of the run.
+func PutHistory(id string, record []byte) error {
+	db, err := openHistoryDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(historyBucket)
+		if err != nil {
+			return err
+		}
+
+		return b.Put([]byte(id), record)
+	})
+}
+
+// Get the records of all runs in the history of runs.
+func GetHistory() ([][]byte, error) {
+	db, err := openHistoryDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var records [][]byte
+	if err := db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(historyBucket)
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(_, v []byte) error {
+			record := make([]byte, len(v))
+			copy(record, v)
+			records = append(records, record)
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func openHistoryDB() (*bbolt.DB, error) {
+	db, err := bbolt.Open(path.Join(viper.GetString("storage"), "history.db"), 0600, &bbolt.Options{})
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"path"
+	"syscall"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.etcd.io/bbolt"
+)
+
+// Name of the bucket that run state is stored within.
+var runBucket = []byte("runs")
+
+// Possible statuses of a playthrough run.
+const (
+	RunRunning = "running"
+	RunFailed  = "failed"
+	RunKept    = "kept"
+)
+
+// `Run` is the state of a playthrough run.
+type Run struct {
+	// Unique id of the run.
+	Id string `json:"id"`
+
+	// Name of the playthrough.
+	Playthrough string `json:"playthrough"`
+
+	// Path to the playthrough file.
+	File string `json:"file"`
+
+	// Name of the provider the run requests instances from.
+	Provider string `json:"provider"`
+
+	// Id of the gambol installation executing the run.
+	Owner string `json:"owner"`
+
+	// Id of the gambol process executing the run.
+	Pid int `json:"pid"`
+
+	// Time the run started.
+	Started time.Time `json:"started"`
+
+	// Status of the run.
+	Status string `json:"status"`
+
+	// Id of the Act currently being executed.
+	Act string `json:"act,omitempty"`
+
+	// Name of the Scene currently being executed.
+	Scene string `json:"scene,omitempty"`
+}
+
+// Check if the run is still being executed by a gambol process.
+func (r *Run) IsActive() bool {
+	if r.Status != RunRunning {
+		return false
+	}
+
+	// Signal 0 only checks if the process exists.
+	err := syscall.Kill(r.Pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// Record the state of a run.
+func PutRun(run Run) error {
+	db, err := openRunDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+
+	return db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(runBucket)
+		if err != nil {
+			return err
+		}
+
+		return b.Put([]byte(run.Id), data)
+	})
+}
+
+// Get the state of all recorded runs.
+func GetRuns() ([]Run, error) {
+	db, err := openRunDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var runs []Run
+	if err := db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(runBucket)
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(_, v []byte) error {
+			var run Run
+			if err := json.Unmarshal(v, &run); err != nil {
+				return err
+			}
+			runs = append(runs, run)
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// Delete the recorded state of a run.
+func DeleteRun(id string) error {
+	db, err := openRunDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(runBucket)
+		if b == nil {
+			return nil
+		}
+
+		return b.Delete([]byte(id))
+	})
+}
+
+func openRunDB() (*bbolt.DB, error) {
+	db, err := bbolt.Open(path.Join(viper.GetString("storage"), "run.db"), 0600, &bbolt.Options{})
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
@@ -1,8 +1,12 @@
 package storage
 
 import (
+	"errors"
 	"os"
+	"path"
+	"strings"
 
+	"github.com/google/uuid"
 	"github.com/spf13/viper"
 )
 
@@ -15,3 +19,29 @@ func Init() error {
 
 	return nil
 }
+
+// Get the id of the gambol installation that owns the runs recorded in
+// the storage directory. The id tells the instances of these runs apart
+// from those of other machines sharing a provider, and is generated on
+// first use.
+func Owner() (string, error) {
+	file := path.Join(viper.GetString("storage"), "owner")
+	data, err := os.ReadFile(file)
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+	owner := hostname + "-" + uuid.NewString()[:8]
+	if err := os.WriteFile(file, []byte(owner+"\n"), 0600); err != nil {
+		return "", err
+	}
+
+	return owner, nil
+}
@@ -0,0 +1,127 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name of the tracer spans of gambol are created with.
+const tracerName = "github.com/nuccitheboss/gambol"
+
+// Attributes recorded on the spans of a playthrough run.
+const (
+	RunKey         = attribute.Key("gambol.run")
+	PlaythroughKey = attribute.Key("gambol.playthrough")
+	ActKey         = attribute.Key("gambol.act")
+	SceneKey       = attribute.Key("gambol.scene")
+	InstanceKey    = attribute.Key("gambol.instance")
+	ArtifactKey    = attribute.Key("gambol.artifact")
+	SizeKey        = attribute.Key("gambol.artifact.size")
+	ExitCodeKey    = attribute.Key("gambol.exit_code")
+)
+
+// `Config` configures where the spans of playthrough runs are exported to.
+type Config struct {
+	// URL of an OTLP/HTTP endpoint, e.g. `http://localhost:4318`.
+	Endpoint string `mapstructure:"endpoint"`
+
+	// File to write spans to as JSON, one span per line.
+	File string `mapstructure:"file"`
+}
+
+// Check if spans are exported anywhere. The OTLP endpoint may
+// also be configured with the standard `OTEL_EXPORTER_OTLP_*`
+// environment variables.
+func (c Config) Enabled() bool {
+	return c.Endpoint != "" || c.File != "" || otlpFromEnv()
+}
+
+func otlpFromEnv() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Set up exporting spans as configured. The returned function flushes
+// the remaining spans and must be called before gambol exits. Nothing
+// is exported if tracing is not configured.
+func Setup(ctx context.Context, config Config, version string) (func(context.Context) error, error) {
+	if !config.Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName("gambol"),
+		semconv.ServiceVersion(version),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	options := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	var closers []func() error
+	if config.Endpoint != "" || otlpFromEnv() {
+		var otlpOptions []otlptracehttp.Option
+		if config.Endpoint != "" {
+			otlpOptions = append(otlpOptions, otlptracehttp.WithEndpointURL(config.Endpoint))
+		}
+		exporter, err := otlptracehttp.New(ctx, otlpOptions...)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+	if config.File != "" {
+		f, err := os.Create(config.File)
+		if err != nil {
+			return nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+		closers = append(closers, f.Close)
+	}
+
+	tp := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(tp)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		slog.Warn("dropped spans that could not be exported", "error", err)
+	}))
+
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		for _, close := range closers {
+			err = errors.Join(err, close())
+		}
+		return err
+	}, nil
+}
+
+// Start a span as a child of the span in ctx, if any.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End a span, marking it as failed if err is set.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}